@@ -3,38 +3,191 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"teriyake/go-git-it/config"
 	"teriyake/go-git-it/gitops"
+	"time"
 )
 
 var (
-	deadline string
+	deadline     string
+	taskPriority string
+	taskFileFlag string
+	taskBody     string
+	taskBodyFile string
+	editBody     bool
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add [task-file] [task-description]",
+	Use:   "add [task-description]",
 	Short: "Add a new task",
-	Long:  `Add a new task by creating a file for the task and committing it with a description.`,
-	Args:  cobra.MinimumNArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		taskFile := args[0]
-		taskDescription := args[1]
-		if err := gitops.AddAndCommit(taskFile, taskDescription); err != nil {
-			fmt.Fprintf(os.Stderr, "Error adding task: %s\n", err)
-			return
+	Long: `Add a new task by creating an issue for it in the current to-do repo.
+The issue body can be given with --body, read from a file with --body-file ("-" for stdin), or written in $EDITOR with --edit.
+With --file, the task file is also copied into the to-do repo, committed and pushed. The commit message names the issue, and Markdown and text files are also tagged with the issue number; other files are copied unchanged.
+The older form "add [task-file] [task-description]" is still accepted.
+Examples: add "Buy milk" --deadline 2024-03-01 --priority high
+          add "Write report" --file report.md`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskFile := taskFileFlag
+		taskDescription := args[0]
+		if len(args) == 2 {
+			if taskFile != "" {
+				return fmt.Errorf("the task file was given both as an argument and with --file")
+			}
+			taskFile, taskDescription = args[0], args[1]
 		}
-		fmt.Printf("Task added: %s\n", taskDescription)
 
+		var due *time.Time
 		if deadline != "" {
-			if err := gitops.SetDeadline(taskDescription, deadline); err != nil {
-				fmt.Printf("failed to set deadline with %v\n", err)
-				return
+			parsed, err := gitops.ParseDeadline(deadline)
+			if err != nil {
+				return err
+			}
+			due = &parsed
+		}
+
+		var priority string
+		if taskPriority != "" {
+			var err error
+			priority, err = gitops.ParsePriority(taskPriority)
+			if err != nil {
+				return err
+			}
+		}
+
+		if taskFile != "" {
+			info, err := os.Stat(taskFile)
+			if err != nil {
+				return fmt.Errorf("invalid task file: %v", err)
+			}
+			if info.IsDir() {
+				return fmt.Errorf("task file %s is a directory", taskFile)
+			}
+		}
+
+		body, err := readTaskBody(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, repo, err := currentRepoClient()
+		if err != nil {
+			return err
+		}
+
+		meta := gitops.TaskMeta{}
+		meta.SetDue(due)
+		meta.SetPriority(priority)
+		if taskFile != "" {
+			meta["file"] = filepath.Base(taskFile)
+		}
+
+		issue, err := client.CreateIssue(repo, taskDescription, meta.Render(body))
+		if err != nil {
+			return fmt.Errorf("error adding task: %w", err)
+		}
+		fmt.Fprintf(infoOut(), "Task added: %s (#%d)\n", taskDescription, issue.Number)
+
+		// A new task has no status label yet, so there is no need to load
+		// the workflow.
+		task := newTaskOutput(issue, nil)
+		op := config.Operation{Kind: config.OpAdd, Repo: repo, Issue: issue.Number}
+		if taskFile != "" {
+			message := fmt.Sprintf("%s (#%d)", taskDescription, issue.Number)
+			fileMeta := gitops.TaskMeta{"issue": strconv.Itoa(issue.Number)}
+			commit, err := gitops.AddAndCommit(client.LocalPath(repo), taskFile, message, fileMeta)
+			if err != nil {
+				recordOperation(op)
+				return fmt.Errorf("issue #%d was created but committing the task file failed: %w", issue.Number, err)
 			}
-			fmt.Println("Deadline set successfully.")
+			op.File, op.Commit = filepath.Base(taskFile), commit
+			fmt.Fprintf(infoOut(), "Task file %s committed.\n", op.File)
 		}
+		recordOperation(op)
+
+		return printResult(task, func() {})
 	},
 }
 
+// readTaskBody returns the issue body selected by --body, --body-file or
+// --edit, or "" if none of them was given.
+func readTaskBody(cmd *cobra.Command) (string, error) {
+	sources := 0
+	for _, name := range []string{"body", "body-file", "edit"} {
+		if cmd.Flags().Changed(name) {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("only one of --body, --body-file and --edit can be used")
+	}
+
+	switch {
+	case taskBodyFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read body from stdin with %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case taskBodyFile != "":
+		data, err := os.ReadFile(taskBodyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read body file with %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case editBody:
+		return editText()
+	}
+	return taskBody, nil
+}
+
+// editText opens $VISUAL or $EDITOR (falling back to vi) on an empty file and
+// returns what the user wrote.
+func editText() (string, error) {
+	if !isInteractive() {
+		return "", errMissingInput("a body")
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "ggi-task-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file with %v", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	editCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	editCmd.Stdin, editCmd.Stdout, editCmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed with %v", editor, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited body with %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 func init() {
 	addCmd.Flags().StringVarP(&deadline, "deadline", "d", "", "Optional deadline for the task (format: YYYY-MM-DD)")
+	addCmd.Flags().StringVarP(&taskPriority, "priority", "p", "", "Optional priority for the task (P0-P3, or urgent, high, medium, low)")
+	addCmd.Flags().StringVarP(&taskFileFlag, "file", "f", "", "Task file to commit to the to-do repo and link to the issue")
+	addCmd.Flags().StringVarP(&taskBody, "body", "b", "", "Body of the task's issue")
+	addCmd.Flags().StringVar(&taskBodyFile, "body-file", "", "Read the body of the task's issue from a file (\"-\" for stdin)")
+	addCmd.Flags().BoolVarP(&editBody, "edit", "e", false, "Write the body of the task's issue in $EDITOR")
 }
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"strings"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <owner/repo>",
+	Short: "Use an existing repo as a to-do repo",
+	Long: `Use an existing repo's issue tracker as a to-do repo, e.g. one of your team's project repos.
+This checks that you can write to the repo, clones it (or reuses an existing clone), creates the status labels it is missing,
+and adds it to your profile as the current to-do repo. 'del-repo' never deletes an adopted repo on GitHub unless --remote is given.
+Example: adopt my-team/website`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
+		}
+		repo, err := config.ParseRepoRef(args[0], client.Username)
+		if err != nil {
+			return err
+		}
+
+		info, err := client.GetRepo(repo)
+		var notFound *gitops.NotFoundError
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("repo %s does not exist or you do not have access to it: %w", repo, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up %s with %w", repo, err)
+		}
+		// Use GitHub's spelling of the owner and name.
+		if info.Owner != nil {
+			repo = config.RepoRef{Owner: info.Owner.Login, Name: info.Name}
+		}
+		switch {
+		case info.Archived:
+			return fmt.Errorf("%s is archived", repo)
+		case !info.HasIssues:
+			return fmt.Errorf("issues are disabled for %s, enable them in the repo settings first", repo)
+		case info.Permissions != nil && !info.Permissions.Push:
+			return fmt.Errorf("you need write access to %s to manage tasks in it", repo)
+		}
+
+		reused, err := client.CloneRepo(repo)
+		if err != nil {
+			return err
+		}
+		if reused {
+			fmt.Fprintf(infoOut(), "Using the existing clone at %s\n", client.LocalPath(repo))
+		} else {
+			fmt.Fprintf(infoOut(), "Cloned %s into %s\n", repo, client.LocalPath(repo))
+		}
+
+		workflow, err := loadWorkflow(client, repo)
+		if err != nil {
+			return err
+		}
+		created, err := client.ProvisionStatusLabels(repo, workflow)
+		if err != nil {
+			return err
+		}
+		if len(created) > 0 {
+			fmt.Fprintf(infoOut(), "Created labels: %s\n", strings.Join(created, ", "))
+		}
+
+		profile, err := config.LoadUserProfile()
+		if err != nil {
+			return fmt.Errorf("failed to load user profile with %v", err)
+		}
+		profile.AdoptRepo(repo)
+		profile.SetCurrentRepo(repo)
+		if err := profile.Save(); err != nil {
+			return fmt.Errorf("failed to save user profile with %v", err)
+		}
+
+		fmt.Fprintf(infoOut(), "Adopted %s as a to-do repo.\n", repo)
+		return nil
+	},
+}
@@ -1,19 +1,18 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"github.com/spf13/cobra"
-	"os"
-	"strconv"
-	"strings"
 	"teriyake/go-git-it/config"
 )
 
 var chooseRepoCmd = &cobra.Command{
-	Use:   "choose-repo",
+	Use:   "choose-repo [repo]",
 	Short: "Choose an existing to-do repo to work with",
-	Long:  `This command allows the user to choose an existing to-do repo from their profile and sets it as the current working directory.`,
+	Long: `This command allows the user to choose an existing to-do repo from their profile and sets it as the current working directory.
+The repo can be given as owner/name, or just by name if that is unambiguous.
+Example: choose-repo groceries`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, err := config.LoadUserProfile()
 		if err != nil {
@@ -21,28 +20,42 @@ var chooseRepoCmd = &cobra.Command{
 		}
 
 		if len(profile.ToDoRepos) == 0 {
-			fmt.Println("No existing to-do repos found. Please use 'new-repo' command to create one.")
+			fmt.Fprintln(infoOut(), "No existing to-do repos found. Please use 'new-repo' command to create one.")
 			return nil
 		}
 
-		fmt.Println("Select a to-do repo by entering the corresponding number:")
-		for i, repo := range profile.ToDoRepos {
-			fmt.Printf("%d. %s\n", i+1, repo)
-		}
-
-		reader := bufio.NewReader(os.Stdin)
-		choice, _ := reader.ReadString('\n')
-		choice = strings.TrimSpace(choice)
-		index, err := strconv.Atoi(choice)
-		if err != nil || index < 1 || index > len(profile.ToDoRepos) {
-			return fmt.Errorf("invalid selection, please enter a number between 1 and %d", len(profile.ToDoRepos))
+		selectedRepo, err := selectRepo(profile, args, "Select a to-do repo by entering the corresponding number:")
+		if err != nil {
+			return err
 		}
 
-		selectedRepo := profile.ToDoRepos[index-1]
-		fmt.Printf("Setting current directory to: %s\n", selectedRepo)
+		fmt.Fprintf(infoOut(), "Setting current directory to: %s\n", selectedRepo)
 		profile.SetCurrentRepo(selectedRepo)
-		profile.Save()
+		if err := profile.Save(); err != nil {
+			return fmt.Errorf("failed to save user profile with %v", err)
+		}
 
 		return nil
 	},
 }
+
+// selectRepo returns the to-do repo named in args, or prompts for one of the
+// repos in profile when no name was given.
+func selectRepo(profile *config.UserProfile, args []string, header string) (config.RepoRef, error) {
+	if len(args) > 0 {
+		return profile.FindRepo(args[0])
+	}
+
+	if !isInteractive() {
+		return config.RepoRef{}, errMissingInput("a repo name")
+	}
+	names := make([]string, len(profile.ToDoRepos))
+	for i, repo := range profile.ToDoRepos {
+		names[i] = repo.String()
+	}
+	index, err := promptChoice(header, names)
+	if err != nil {
+		return config.RepoRef{}, err
+	}
+	return profile.ToDoRepos[index], nil
+}
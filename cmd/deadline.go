@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"time"
+)
+
+var clearDeadline bool
+
+var deadlineCmd = &cobra.Command{
+	Use:   "deadline [issue number] [YYYY-MM-DD]",
+	Short: "Change or clear the deadline of a task",
+	Long: `Change the deadline of an existing task, or remove it with --clear.
+Examples: deadline 2 2024-03-01
+          deadline 2 --clear`,
+	Args: taskFieldArgs(&clearDeadline),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var due *time.Time
+		if !clearDeadline {
+			parsed, err := gitops.ParseDeadline(args[1])
+			if err != nil {
+				return err
+			}
+			due = &parsed
+		}
+
+		return updateTask(args[0], func(client *gitops.Client, repo config.RepoRef, issue *gitops.Issue) (*gitops.Issue, error) {
+			previous := formatDue(issue.DueOn())
+			issue, err := client.SetIssueDeadline(repo, issue, due)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set deadline with %w", err)
+			}
+			recordOperation(config.Operation{Kind: config.OpDeadline, Repo: repo, Issue: issue.Number, Value: previous})
+			return issue, nil
+		}, func(issue *gitops.Issue) {
+			if due == nil {
+				fmt.Printf("Deadline of issue #%d cleared.\n", issue.Number)
+			} else {
+				fmt.Printf("Deadline of issue #%d set to %s.\n", issue.Number, formatDue(due))
+			}
+		})
+	},
+}
+
+func init() {
+	deadlineCmd.Flags().BoolVar(&clearDeadline, "clear", false, "Remove the deadline instead of setting one")
+}
@@ -1,20 +1,23 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
 	"teriyake/go-git-it/config"
 	"teriyake/go-git-it/gitops"
 )
 
+var delRepoRemote bool
+
 var delRepoCmd = &cobra.Command{
-	Use:   "del-repo",
+	Use:   "del-repo [repo]",
 	Short: "Delete an existing to-do repo",
-	Long:  `This command deletes an existing to-do repo, both locally and remotely, and updates the user profile.`,
+	Long: `This command deletes an existing to-do repo, both locally and remotely, and updates the user profile.
+Repos added with 'adopt', or that ggi did not create, are only removed from the profile and deleted locally unless --remote is given.
+If no repo is given, the user selects one from their profile. Pass --yes to skip the confirmation prompt.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, err := config.LoadUserProfile()
 		if err != nil {
@@ -22,54 +25,74 @@ var delRepoCmd = &cobra.Command{
 		}
 
 		if len(profile.ToDoRepos) == 0 {
-			fmt.Println("No existing to-do repos found.")
+			fmt.Fprintln(infoOut(), "No existing to-do repos found.")
 			return nil
 		}
 
-		fmt.Println("Select a to-do repo to delete by entering the corresponding number:")
-		for i, repo := range profile.ToDoRepos {
-			fmt.Printf("%d. %s\n", i+1, repo)
+		selectedRepo, err := selectRepo(profile, args, "Select a to-do repo to delete by entering the corresponding number:")
+		if err != nil {
+			return err
 		}
 
-		var index int
-		fmt.Print("Enter number: ")
-		_, err = fmt.Scan(&index)
-		if err != nil || index < 1 || index > len(profile.ToDoRepos) {
-			return fmt.Errorf("invalid selection")
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
 		}
 
-		selectedRepo := profile.ToDoRepos[index-1]
+		// Only repos ggi created are deleted on GitHub, unless --remote says
+		// otherwise; adopted repos are often a team's real project.
+		deleteRemote := delRepoRemote
+		if !deleteRemote && !profile.IsAdopted(selectedRepo) {
+			info, err := client.GetRepo(selectedRepo)
+			var notFound *gitops.NotFoundError
+			switch {
+			case errors.As(err, &notFound):
+				// Already gone on GitHub.
+			case err != nil:
+				return fmt.Errorf("failed to look up %s with %w", selectedRepo, err)
+			default:
+				deleteRemote = info.IsToDoRepo()
+			}
+		}
 
-		fmt.Printf("Are you sure you want to delete %s? [y/N]: ", selectedRepo)
-		var confirmation string
-		_, err = fmt.Scan(&confirmation)
-		if err != nil || strings.ToLower(confirmation) != "y" {
-			fmt.Println("Deletion cancelled.")
+		question := fmt.Sprintf("Are you sure you want to delete %s?", selectedRepo)
+		if !deleteRemote {
+			question = fmt.Sprintf("Remove %s from your profile and delete its local clone? The repo on GitHub is kept.", selectedRepo)
+		}
+		ok, err := confirm(question)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(infoOut(), "Deletion cancelled.")
 			return nil
 		}
 
-		err = gitops.DeleteRemoteRepo(selectedRepo)
-		if err != nil {
-			return fmt.Errorf("failed to delete remote repo:\n%v", err)
+		if deleteRemote {
+			if err := client.DeleteRemoteRepo(selectedRepo); err != nil {
+				return fmt.Errorf("failed to delete remote repo:\n%w", err)
+			}
 		}
 
-		localRepoPath := filepath.Join(os.Getenv("HOME"), ".go-git-it", "repos", selectedRepo)
-		rmCmd := exec.Command("rm", "-rf", localRepoPath)
-		err = rmCmd.Run()
-		if err != nil {
+		localRepoPath := client.LocalPath(selectedRepo)
+		if err := os.RemoveAll(localRepoPath); err != nil {
 			return fmt.Errorf("failed to delete repo located at %s with %v", localRepoPath, err)
 		}
 
 		profile.RemoveRepo(selectedRepo)
-		profile.Save()
-		if profile.GetCurrentRepo() == selectedRepo {
-			profile.SetCurrentRepo("")
-		}
 		if err := profile.Save(); err != nil {
 			return fmt.Errorf("failed to update user profile with %v", err)
 		}
 
-		fmt.Printf("Repository %s has been deleted.\n", selectedRepo)
+		if deleteRemote {
+			fmt.Fprintf(infoOut(), "Repository %s has been deleted.\n", selectedRepo)
+		} else {
+			fmt.Fprintf(infoOut(), "Repository %s has been removed from your profile; pass --remote to also delete it on GitHub.\n", selectedRepo)
+		}
 		return nil
 	},
 }
+
+func init() {
+	delRepoCmd.Flags().BoolVar(&delRepoRemote, "remote", false, "Also delete the repo on GitHub even if ggi did not create it")
+}
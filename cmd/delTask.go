@@ -4,63 +4,84 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"teriyake/go-git-it/config"
-	"teriyake/go-git-it/gitops"
 )
 
 var delTaskCmd = &cobra.Command{
-	Use:   "del-task",
+	Use:   "del-task [task-file]",
 	Short: "Delete a task file in the current to-do repo",
-	Long:  `This command lists all the files in the current to-do repo and deletes the file selected by the user. The deletion is done both locally and remotely.`,
+	Long: `This command deletes a task file in the current to-do repo, both locally and remotely.
+If no file is given, it lists all the files in the current to-do repo and deletes the one selected by the user.
+Pass --yes to skip the confirmation prompt.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		profile, err := config.LoadUserProfile()
+		client, repo, err := currentRepoClient()
 		if err != nil {
-			return fmt.Errorf("failed to load user profile with %v", err)
+			return err
 		}
-		repoName := profile.GetCurrentRepo()
-		repoPath := filepath.Join(os.Getenv("HOME"), ".go-git-it", "repos", repoName)
+		repoPath := client.LocalPath(repo)
 
-		lsCmd := exec.Command("ls", "-1", repoPath)
-		output, err := lsCmd.Output()
-		if err != nil {
-			return fmt.Errorf("failed to list filesw with %v", err)
-		}
-		files := strings.Split(string(output), "\n")
-		for i, file := range files {
-			fmt.Printf("%d: %s\n", i+1, file)
+		var selectedFile string
+		if len(args) > 0 {
+			selectedFile = filepath.Base(args[0])
+			if _, err := os.Stat(filepath.Join(repoPath, selectedFile)); err != nil {
+				return fmt.Errorf("task file %s not found in %s", selectedFile, repo)
+			}
+		} else {
+			if !isInteractive() {
+				return errMissingInput("a task file")
+			}
+			entries, err := os.ReadDir(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to list files with %v", err)
+			}
+			var files []string
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					files = append(files, entry.Name())
+				}
+			}
+			if len(files) == 0 {
+				fmt.Fprintln(infoOut(), "No task files found.")
+				return nil
+			}
+			choice, err := promptChoice("Select a file to delete:", files)
+			if err != nil {
+				return err
+			}
+			selectedFile = files[choice]
 		}
 
-		var choice int
-		fmt.Print("Select a file to delete: ")
-		_, err = fmt.Scan(&choice)
-		if err != nil || choice < 1 || choice > len(files) {
-			return fmt.Errorf("invalid selection")
+		ok, err := confirm(fmt.Sprintf("Are you sure you want to delete %s?", selectedFile))
+		if err != nil {
+			return err
 		}
-		selectedFile := files[choice-1]
-
-		fmt.Printf("Are you sure you want to delete %s? [y/N]: ", selectedFile)
-		var confirmation string
-		_, err = fmt.Scan(&confirmation)
-		if err != nil || strings.ToLower(confirmation) != "y" {
-			fmt.Println("Deletion cancelled.")
+		if !ok {
+			fmt.Fprintln(infoOut(), "Deletion cancelled.")
 			return nil
 		}
 
-		localFilePath := filepath.Join(os.Getenv("HOME"), ".go-git-it", "repos", repoName, selectedFile)
-		rmCmd := exec.Command("rm", "-f", localFilePath)
-		err = rmCmd.Run()
-		if err != nil {
+		localFilePath := filepath.Join(repoPath, selectedFile)
+		if err := os.Remove(localFilePath); err != nil {
 			return fmt.Errorf("failed to delete file located at %s with %v", localFilePath, err)
 		}
 
-		fmt.Println("Deleted", selectedFile, "locally. \nNow deleting ", selectedFile, " remotely...")
-		sha, e := gitops.GetFileSHA(repoName, selectedFile)
+		fmt.Fprintln(infoOut(), "Deleted", selectedFile, "locally. \nNow deleting ", selectedFile, " remotely...")
+		sha, e := client.GetFileSHA(repo, selectedFile)
 		if e != nil {
-			return fmt.Errorf("failed to get file SHA: %v", e)
+			return fmt.Errorf("failed to get file SHA: %w", e)
+		}
+		commit, err := client.DeleteRemoteFile(repo, selectedFile, sha)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(infoOut(), "Deleted %s remotely.\n", selectedFile)
+
+		err = config.RecordOperation(config.Operation{Kind: config.OpDeleteTask, Repo: repo, File: selectedFile, Commit: commit})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record the change for undo with %v\n", err)
 		}
-		return gitops.DeleteRemoteFile(repoName, selectedFile, sha)
+		return nil
 	},
 }
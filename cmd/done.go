@@ -8,50 +8,82 @@ import (
 )
 
 var doneCmd = &cobra.Command{
-	Use:   "done",
+	Use:   "done [issue number]",
 	Short: "Mark a to-do item as done by closing the corresponding Github issue",
-	Long:  `This command lists all open issues in the current to-do repo, and the user will select one to close.`,
+	Long: `This command closes the issue of a to-do item in the current to-do repo and marks it with the workflow's closing status ("done" by default).
+If no issue number is given, it lists all open issues and the user will select one to close.
+Example: done 2`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		profile, err := config.LoadUserProfile()
+		client, repo, err := currentRepoClient()
 		if err != nil {
-			return fmt.Errorf("failed to load user profile with %v", err)
-		}
-		repoName := profile.GetCurrentRepo()
-
-		issues, err := gitops.ListIssues(repoName)
-		if err != nil {
-			fmt.Println("Error listing issues: ", err)
 			return err
 		}
 
-		if len(issues) == 0 {
-			fmt.Println("No issues found.")
-			return nil
-		}
-
-		fmt.Println("Select an ongoing to-do item by number:")
-		for _, issue := range issues {
-			if issue.State == "open" {
-				fmt.Printf("#%d: %s\n", issue.Number, issue.Title)
+		var issueNumber int
+		if len(args) > 0 {
+			issueNumber, err = parseIssueNumber(args[0])
+			if err != nil {
+				return err
+			}
+		} else {
+			if !isInteractive() {
+				return errMissingInput("an issue number")
+			}
+			issueNumber, err = selectIssue(client, repo, "open", "Select an ongoing to-do item by number:")
+			if err != nil {
+				return err
+			}
+			if issueNumber == 0 {
+				return printResult([]TaskOutput{}, func() {})
 			}
 		}
 
-		var issueNumber int
-		fmt.Print("Issue number: ")
-		_, err = fmt.Scan(&issueNumber)
+		workflow, err := loadWorkflow(client, repo)
 		if err != nil {
-			fmt.Println("Invalid input:", err)
 			return err
 		}
-
-		err = gitops.CloseIssue(repoName, issueNumber)
+		closing := workflow.ClosingState()
+		if closing == "" {
+			return fmt.Errorf("the workflow of %s has no status that closes tasks, see %s", repo, gitops.WorkflowPath)
+		}
+		before, err := client.GetIssue(repo, issueNumber)
 		if err != nil {
-			fmt.Println("Error closing issue: ", err)
 			return err
 		}
+		issue, err := client.SetStatus(repo, workflow, before, closing)
+		if err != nil {
+			return fmt.Errorf("error closing issue: %w", err)
+		}
+		recordIssueChange(config.OpClose, repo, before)
 
-		fmt.Printf("To-do item associated with issue #%d completed successfully.\n", issueNumber)
-		return nil
+		return printResult(newTaskOutput(issue, workflow), func() {
+			fmt.Printf("To-do item associated with issue #%d completed successfully.\n", issueNumber)
+		})
 	},
 }
+
+// selectIssue lists the issues of repo in state ("open" or "closed") and
+// prompts for one of them. It returns 0 if there is nothing to choose from.
+func selectIssue(client *gitops.Client, repo config.RepoRef, state, header string) (int, error) {
+	issues, err := client.ListIssues(repo, &gitops.IssueListOptions{State: state})
+	if err != nil {
+		return 0, fmt.Errorf("error listing issues: %w", err)
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(infoOut(), "No issues found.")
+		return 0, nil
+	}
+
+	fmt.Fprintln(infoOut(), header)
+	for _, issue := range issues {
+		fmt.Fprintf(infoOut(), "#%d: %s\n", issue.Number, issue.Title)
+	}
+
+	answer, err := promptLine("Issue number: ")
+	if err != nil {
+		return 0, fmt.Errorf("invalid input: %v", err)
+	}
+	return parseIssueNumber(answer)
+}
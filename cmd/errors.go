@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+const (
+	ExitOK = iota
+	ExitError
+	ExitNotFound
+	ExitAuth
+	ExitRateLimited
+	ExitValidation
+)
+
+// ExitCode maps an error returned by Execute to the process exit status, so
+// scripts can tell authentication and rate-limit failures apart from others.
+func ExitCode(err error) int {
+	var (
+		notFound     *gitops.NotFoundError
+		unauthorized *gitops.UnauthorizedError
+		rateLimited  *gitops.RateLimitError
+		validation   *gitops.ValidationError
+	)
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, config.ErrNotAuthenticated), errors.Is(err, config.ErrSessionExpired), errors.As(err, &unauthorized):
+		return ExitAuth
+	case errors.As(err, &rateLimited):
+		return ExitRateLimited
+	case errors.As(err, &notFound):
+		return ExitNotFound
+	case errors.As(err, &validation):
+		return ExitValidation
+	}
+	return ExitError
+}
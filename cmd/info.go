@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
-	"os"
 	"teriyake/go-git-it/config"
 	"teriyake/go-git-it/gitauth"
 	"teriyake/go-git-it/gitops"
@@ -13,20 +12,38 @@ var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Info on current user",
 	Long:  `Display the current user's profile, including the auth status, the list of to-do repos, and current repos.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if gitops.HasToken() {
-			fmt.Printf("Git token exists!\n")
-			me := gitauth.Whoami()
-			fmt.Printf("You are %s\n", me)
-		} else {
-			fmt.Printf("Git token does not exist. Please use the 'login' sub-command to authorize first.\n")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hasToken := gitops.HasToken()
+		var me string
+		if hasToken {
+			var err error
+			me, err = gitauth.Whoami()
+			if err != nil {
+				return err
+			}
 		}
 		profile, err := config.LoadUserProfile()
 		if err != nil {
-			fmt.Errorf("failed to load user profile: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to load user profile: %v", err)
 		}
-		fmt.Printf("Existing to-do repos:\n%v\n", profile.ListRepos())
-		fmt.Printf("Current to-do repo: %v\n", profile.GetCurrentRepo())
+
+		out := newProfileOutput(profile, hasToken)
+		if me != "" {
+			out.Username = me
+		}
+		return printResult(out, func() {
+			fmt.Printf("Profile: %s\n", profile.Name())
+			if hasToken {
+				fmt.Printf("Git token exists!\n")
+				fmt.Printf("You are %s\n", me)
+			} else {
+				fmt.Printf("Git token does not exist. Please use the 'login' sub-command to authorize first.\n")
+			}
+			fmt.Printf("Existing to-do repos:\n")
+			for _, repo := range profile.ListRepos() {
+				fmt.Printf("- %s\n", repo)
+			}
+			fmt.Printf("Current to-do repo: %v\n", profile.GetCurrentRepo())
+		})
 	},
 }
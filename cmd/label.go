@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"strings"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "Add or remove free-form labels on a task",
+	Long: `Tag tasks in the current to-do repo with labels such as "bug" or "errand". Labels that don't exist in the repo yet are created.
+Status labels are changed with 'mark' instead, so that the workflow is followed.
+Example: label add 3 errand urgent`,
+}
+
+var labelAddCmd = &cobra.Command{
+	Use:   "add <issue number> <label>...",
+	Short: "Add labels to a task",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, repo, workflow, issue, err := loadLabelTask(args)
+		if err != nil {
+			return err
+		}
+
+		labels, err := client.AddIssueLabels(repo, issue.Number, args[1:])
+		if err != nil {
+			return fmt.Errorf("error adding labels: %w", err)
+		}
+		recordIssueChange(config.OpLabel, repo, issue)
+		issue.Labels = labels
+
+		return printResult(newTaskOutput(issue, workflow), func() {
+			fmt.Printf("Added %s to issue #%d.\n", strings.Join(args[1:], ", "), issue.Number)
+		})
+	},
+}
+
+var labelRemoveCmd = &cobra.Command{
+	Use:     "remove <issue number> <label>...",
+	Aliases: []string{"rm"},
+	Short:   "Remove labels from a task",
+	Args:    cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, repo, workflow, issue, err := loadLabelTask(args)
+		if err != nil {
+			return err
+		}
+		for _, label := range args[1:] {
+			if !issue.HasLabel(label) {
+				return fmt.Errorf("issue #%d has no label %s", issue.Number, label)
+			}
+		}
+
+		before := *issue
+		for i, label := range args[1:] {
+			labels, err := client.RemoveIssueLabel(repo, issue.Number, label)
+			if err != nil {
+				if i > 0 {
+					recordIssueChange(config.OpLabel, repo, &before)
+				}
+				return fmt.Errorf("error removing label %s: %w", label, err)
+			}
+			issue.Labels = labels
+		}
+		recordIssueChange(config.OpLabel, repo, &before)
+
+		return printResult(newTaskOutput(issue, workflow), func() {
+			fmt.Printf("Removed %s from issue #%d.\n", strings.Join(args[1:], ", "), issue.Number)
+		})
+	},
+}
+
+// loadLabelTask looks up the task named by the first argument of the label
+// commands and rejects status labels among the other arguments.
+func loadLabelTask(args []string) (*gitops.Client, config.RepoRef, *gitops.Workflow, *gitops.Issue, error) {
+	issueNumber, err := parseIssueNumber(args[0])
+	if err != nil {
+		return nil, config.RepoRef{}, nil, nil, err
+	}
+	client, repo, err := currentRepoClient()
+	if err != nil {
+		return nil, config.RepoRef{}, nil, nil, err
+	}
+	workflow, err := loadWorkflow(client, repo)
+	if err != nil {
+		return nil, config.RepoRef{}, nil, nil, err
+	}
+	for _, label := range args[1:] {
+		if workflow.State(label) != nil {
+			return nil, config.RepoRef{}, nil, nil, fmt.Errorf("%s is a status, use 'mark %d %s' to change it", label, issueNumber, label)
+		}
+	}
+
+	issue, err := client.GetIssue(repo, issueNumber)
+	if err != nil {
+		return nil, config.RepoRef{}, nil, nil, err
+	}
+	return client, repo, workflow, issue, nil
+}
+
+func init() {
+	labelCmd.AddCommand(labelAddCmd)
+	labelCmd.AddCommand(labelRemoveCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"strings"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var labelsDryRun bool
+
+var labelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Manage the status labels of a to-do repo",
+}
+
+var labelsSyncCmd = &cobra.Command{
+	Use:   "sync [repo]",
+	Short: "Create or fix the status labels of a to-do repo",
+	Long: `Make sure the to-do repo has a label for each status of its workflow, with the color and description the workflow gives it.
+Missing labels are created and labels that drifted (e.g. recolored on GitHub) are reset. Other labels are left untouched.
+Defaults to the current to-do repo. Pass --dry-run to only report the drift.
+Example: labels sync --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, err := config.LoadUserProfile()
+		if err != nil {
+			return fmt.Errorf("failed to load user profile with %v", err)
+		}
+
+		repo := profile.GetCurrentRepo()
+		if len(args) > 0 {
+			repo, err = profile.FindRepo(args[0])
+			if err != nil {
+				return err
+			}
+		}
+		if repo.IsZero() {
+			return fmt.Errorf("no current to-do repo, use 'choose-repo' to select one")
+		}
+
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
+		}
+		workflow, err := loadWorkflow(client, repo)
+		if err != nil {
+			return err
+		}
+		results, err := client.SyncStatusLabels(repo, workflow, labelsDryRun)
+		if err != nil {
+			return err
+		}
+
+		out := make([]LabelSyncOutput, 0, len(results))
+		for _, r := range results {
+			drift := r.Drift
+			if drift == nil {
+				drift = []string{}
+			}
+			out = append(out, LabelSyncOutput{Name: r.Name, Action: r.Action, Drift: drift})
+		}
+
+		return printResult(out, func() {
+			for _, r := range results {
+				switch {
+				case r.Action == gitops.LabelInSync:
+					fmt.Printf("%s: in sync\n", r.Name)
+				case labelsDryRun:
+					fmt.Printf("%s: would be %s (%s)\n", r.Name, r.Action, strings.Join(r.Drift, ", "))
+				default:
+					fmt.Printf("%s: %s (was %s)\n", r.Name, r.Action, strings.Join(r.Drift, ", "))
+				}
+			}
+		})
+	},
+}
+
+func init() {
+	labelsSyncCmd.Flags().BoolVar(&labelsDryRun, "dry-run", false, "Only report drift without changing any labels")
+
+	labelsCmd.AddCommand(labelsSyncCmd)
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strings"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	listStatus    string
+	listLabels    []string
+	listAssignee  string
+	listDueBefore string
+	listOverdue   bool
+	listClosed    bool
+	listAll       bool
+	listSort      string
+	listReverse   bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tasks in the current to-do repo",
+	Long: `List the tasks in the current to-do repo along with their status, deadline and assignees.
+Only open tasks are shown unless --closed or --all is given.
+Tasks are sorted by priority and then by deadline unless --sort says otherwise.
+Example: list --status doing --sort due`,
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listClosed && listAll {
+			return fmt.Errorf("--closed and --all cannot be used together")
+		}
+
+		var dueBefore time.Time
+		if listDueBefore != "" {
+			var err error
+			dueBefore, err = gitops.ParseDeadline(listDueBefore)
+			if err != nil {
+				return fmt.Errorf("invalid --due-before date: %v", err)
+			}
+		}
+
+		client, repo, err := currentRepoClient()
+		if err != nil {
+			return err
+		}
+
+		opts := &gitops.IssueListOptions{State: "open", Assignee: listAssignee}
+		if listClosed {
+			opts.State = "closed"
+		} else if listAll {
+			opts.State = "all"
+		}
+		workflow, err := loadWorkflow(client, repo)
+		if err != nil {
+			return err
+		}
+
+		opts.Labels = append(opts.Labels, listLabels...)
+		if listStatus != "" {
+			state := workflow.State(listStatus)
+			if state == nil {
+				return fmt.Errorf("invalid status %q, expected one of %s", listStatus, strings.Join(workflow.Names(), ", "))
+			}
+			opts.Labels = append(opts.Labels, state.Name)
+		}
+
+		now := time.Now()
+		var issues []gitops.Issue
+		it := client.Issues(repo, opts)
+		for it.Next() {
+			issue := it.Value()
+			due := issue.DueOn()
+			if !dueBefore.IsZero() && (due == nil || !due.Before(dueBefore)) {
+				continue
+			}
+			if listOverdue && (issue.State != "open" || !isOverdue(due, now)) {
+				continue
+			}
+			issues = append(issues, issue)
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		if err := sortIssues(issues, listSort, listReverse); err != nil {
+			return err
+		}
+
+		return printResult(newTaskOutputs(issues, workflow), func() {
+			printTaskTable(issues, workflow)
+		})
+	},
+}
+
+func printTaskTable(issues []gitops.Issue, workflow *gitops.Workflow) {
+	if len(issues) == 0 {
+		fmt.Println("No tasks found.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTITLE\tSTATUS\tPRIORITY\tDUE\tASSIGNEES")
+	for _, issue := range issues {
+		status := workflow.StatusOf(&issue)
+		if issue.State == "closed" && status == "" {
+			status = "closed"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", issue.Number, issue.Title, orDash(status), orDash(issue.Priority()), orDash(formatDue(issue.DueOn())), orDash(strings.Join(issue.AssigneeLogins(), ", ")))
+	}
+	w.Flush()
+}
+
+// loadWorkflow fetches the workflow of repo, which defines the statuses its
+// tasks can have.
+func loadWorkflow(client *gitops.Client, repo config.RepoRef) (*gitops.Workflow, error) {
+	workflow, err := client.GetWorkflow(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the workflow of %s with %w", repo, err)
+	}
+	return workflow, nil
+}
+
+// sortIssues orders issues by "priority" (then deadline), "due", "created" or
+// "number". Tasks without a priority or deadline sort after those with one.
+func sortIssues(issues []gitops.Issue, by string, reverse bool) error {
+	var less func(a, b *gitops.Issue) bool
+	switch by {
+	case "", "priority":
+		less = gitops.ByPriority
+	case "number":
+		less = func(a, b *gitops.Issue) bool { return a.Number < b.Number }
+	case "created":
+		less = func(a, b *gitops.Issue) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "due":
+		less = gitops.ByDue
+	default:
+		return fmt.Errorf("invalid sort key %q, expected one of priority, due, created, number", by)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if reverse {
+			return less(&issues[j], &issues[i])
+		}
+		return less(&issues[i], &issues[j])
+	})
+	return nil
+}
+
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.Format("2006-01-02")
+}
+
+// isOverdue reports whether a task due on the calendar date of due is
+// overdue at now. Deadlines are dates without a time, so a task only becomes
+// overdue once its whole due day has passed in local time.
+func isOverdue(due *time.Time, now time.Time) bool {
+	if due == nil {
+		return false
+	}
+	nextDay := time.Date(due.Year(), due.Month(), due.Day()+1, 0, 0, 0, 0, now.Location())
+	return !now.Before(nextDay)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&listStatus, "status", "s", "", "Only show tasks with this status, a status from the repo's workflow")
+	listCmd.Flags().StringSliceVarP(&listLabels, "label", "l", nil, "Only show tasks with all of these labels")
+	listCmd.Flags().StringVarP(&listAssignee, "assignee", "a", "", "Only show tasks assigned to this user ('none' for unassigned, '*' for any)")
+	listCmd.Flags().StringVar(&listDueBefore, "due-before", "", "Only show tasks due before this date (format: YYYY-MM-DD)")
+	listCmd.Flags().BoolVar(&listOverdue, "overdue", false, "Only show open tasks whose deadline has passed")
+	listCmd.Flags().BoolVar(&listClosed, "closed", false, "Show closed tasks instead of open ones")
+	listCmd.Flags().BoolVar(&listAll, "all", false, "Show both open and closed tasks")
+	listCmd.Flags().StringVar(&listSort, "sort", "priority", "Sort tasks by priority (then due), due, created or number")
+	listCmd.Flags().BoolVarP(&listReverse, "reverse", "r", false, "Reverse the sort order")
+}
@@ -1,17 +1,129 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"teriyake/go-git-it/config"
 	"teriyake/go-git-it/gitauth"
 )
 
+var (
+	loginHost      string
+	loginClientID  string
+	loginStore     string
+	withToken      bool
+	tokenHelper    string
+	appID          string
+	installationID int64
+	appKeyPath     string
+)
+
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Set up Github credentials",
-	Long:  `Set up credentials to grant ggi access to perform Git operations on your behalf via Github API calls.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Set up credentials to grant ggi access to perform Git operations on your behalf via Github API calls.
+Use --host to sign in to a GitHub Enterprise Server instance instead of github.com.
+The token is kept in the OS keyring by default. Use --store encrypted-file to keep it in a passphrase-protected file instead
+(e.g. on machines without a keyring), or --store file for the old plaintext file.
+
+Instead of the browser flow, --with-token reads a personal access token from stdin, e.g.
+  ggi login --with-token < mytoken.txt
+and --token-helper makes ggi ask a command for the token every time, e.g.
+  ggi login --token-helper "gh auth token"
+$GGI_TOKEN, $GH_TOKEN and $GITHUB_TOKEN take precedence over all of these.
+
+To act as a GitHub App installation (e.g. a shared team bot managing an org's to-do repos), pass the app's ID,
+the installation ID and the path to the app's private key:
+  ggi login --app-id 12345 --installation-id 67890 --private-key ./bot.private-key.pem
+Installation tokens are requested and renewed automatically.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("host") || cmd.Flags().Changed("client-id") {
+			profile, err := config.LoadUserProfile()
+			if err != nil {
+				return fmt.Errorf("failed to load user profile with %v", err)
+			}
+			if cmd.Flags().Changed("host") {
+				profile.SetHost(loginHost)
+			}
+			if cmd.Flags().Changed("client-id") {
+				profile.SetClientID(loginClientID)
+			}
+			if err := profile.Save(); err != nil {
+				return fmt.Errorf("failed to save user profile with %v", err)
+			}
+		}
+
+		appLogin := cmd.Flags().Changed("app-id") || cmd.Flags().Changed("installation-id") || cmd.Flags().Changed("private-key")
+		modes := 0
+		for _, used := range []bool{withToken, cmd.Flags().Changed("token-helper"), appLogin} {
+			if used {
+				modes++
+			}
+		}
+
+		switch {
+		case modes > 1:
+			return errors.New("only one of --with-token, --token-helper and --app-id can be used")
+		case appLogin:
+			if appID == "" || installationID == 0 || appKeyPath == "" {
+				return errors.New("--app-id, --installation-id and --private-key must be given together")
+			}
+			keyPath, err := filepath.Abs(appKeyPath)
+			if err != nil {
+				return err
+			}
+			account, err := gitauth.LoginApp(appID, installationID, keyPath)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Logged in as installation %d of app %s on %s.\n", installationID, appID, account)
+			return nil
+		case withToken:
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read token from stdin with %v", err)
+			}
+			token := strings.TrimSpace(string(data))
+			if token == "" {
+				return errors.New("no token was given on stdin")
+			}
+			username, err := gitauth.LoginWithToken(loginStore, token)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Logged in as %s.\n", username)
+			return nil
+		case cmd.Flags().Changed("token-helper"):
+			username, err := gitauth.SetTokenHelper(tokenHelper)
+			if err != nil {
+				return err
+			}
+			if tokenHelper == "" {
+				fmt.Printf("Token helper removed.\n")
+			} else {
+				fmt.Printf("Logged in as %s using %q.\n", username, tokenHelper)
+			}
+			return nil
+		}
+
 		fmt.Printf("Please follow the prompts to log in...\n")
-		gitauth.Login()
+		return gitauth.Login(loginStore)
 	},
 }
+
+func init() {
+	loginCmd.Flags().StringVar(&loginHost, "host", config.DefaultHost, "GitHub hostname to authenticate with (e.g. a GitHub Enterprise Server instance)")
+	loginCmd.Flags().StringVar(&loginClientID, "client-id", "", "OAuth client ID of the ggi app registered on the host")
+	loginCmd.Flags().StringVar(&loginStore, "store", config.StoreKeyring, "Where to keep the token: keyring, encrypted-file or file")
+	loginCmd.Flags().BoolVar(&withToken, "with-token", false, "Read a personal access token from stdin instead of using the browser")
+	loginCmd.Flags().StringVar(&tokenHelper, "token-helper", "", "Get the token from this command's output (an empty value removes the helper)")
+	loginCmd.Flags().StringVar(&appID, "app-id", "", "ID of a GitHub App to authenticate as")
+	loginCmd.Flags().Int64Var(&installationID, "installation-id", 0, "ID of the GitHub App's installation to act as")
+	loginCmd.Flags().StringVar(&appKeyPath, "private-key", "", "Path to the GitHub App's private key (PEM)")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitauth"
+)
+
+var purgeRepos bool
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Sign out and remove stored Github credentials",
+	Long: `Revoke the Github token where possible, remove it from the credential store it was saved in by 'login', and forget the username.
+Revoking requires the app's client secret (set $GGI_CLIENT_SECRET); without it the token is only removed locally.
+With --purge, the local clones of your to-do repos are deleted as well. The remote repos are left untouched.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reposDir := config.ReposDir()
+		if purgeRepos {
+			ok, err := confirm(fmt.Sprintf("Are you sure you want to delete all local clones in %s?", reposDir))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(infoOut(), "Logout cancelled.")
+				return nil
+			}
+		}
+
+		revoked, err := gitauth.Logout()
+		if err != nil {
+			return fmt.Errorf("failed to log out with %w", err)
+		}
+		if revoked {
+			fmt.Fprintln(infoOut(), "Token revoked.")
+		}
+
+		if purgeRepos {
+			if err := os.RemoveAll(reposDir); err != nil {
+				return fmt.Errorf("failed to delete local repos in %s with %v", reposDir, err)
+			}
+			fmt.Fprintf(infoOut(), "Deleted local repos in %s.\n", reposDir)
+		}
+
+		fmt.Fprintln(infoOut(), "Logged out.")
+		return nil
+	},
+}
+
+func init() {
+	logoutCmd.Flags().BoolVar(&purgeRepos, "purge", false, "Also delete the local clones of your to-do repos")
+}
@@ -3,70 +3,74 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"strings"
 	"teriyake/go-git-it/config"
-	"teriyake/go-git-it/gitops"
 )
 
 var markCmd = &cobra.Command{
-	Use:   "mark [issue number] ['done'/'doing'/'will-do']",
+	Use:   "mark [issue number] [status]",
 	Short: "Mark a to-do item with a status",
-	Long: `Mark a to-do item as "done", "doing", or "will-do".
+	Long: `Mark a to-do item with one of the statuses of the to-do repo's workflow, "will-do", "doing" or "done" by default.
+Only the moves the workflow allows are accepted, and moving into or out of a closing status like "done" closes or reopens the issue.
+Any argument that is left out is prompted for when running in a terminal.
 Example: mark 2 doing`,
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-
-		profile, err := config.LoadUserProfile()
-		if err != nil {
-			return fmt.Errorf("failed to load user profile with %v", err)
-		}
-
-		repoName := profile.GetCurrentRepo()
-
-		issues, err := gitops.ListIssues(repoName)
+		client, repo, err := currentRepoClient()
 		if err != nil {
-			fmt.Println("Error listing to-do items:", err)
 			return err
 		}
 
-		if len(issues) == 0 {
-			fmt.Println("No to-do items with issues found.")
-			return nil
+		var issueNumber int
+		if len(args) > 0 {
+			issueNumber, err = parseIssueNumber(args[0])
+			if err != nil {
+				return err
+			}
+		} else {
+			if !isInteractive() {
+				return errMissingInput("an issue number")
+			}
+			issueNumber, err = selectIssue(client, repo, "open", "Select an issue by number:")
+			if err != nil {
+				return err
+			}
+			if issueNumber == 0 {
+				return printResult([]TaskOutput{}, func() {})
+			}
 		}
 
-		fmt.Println("Select an issue by number:")
-		for _, issue := range issues {
-			fmt.Printf("#%d: %s\n", issue.Number, issue.Title)
+		workflow, err := loadWorkflow(client, repo)
+		if err != nil {
+			return err
 		}
-
-		var issueNumber int
-		fmt.Print("Issue number: ")
-		_, err = fmt.Scan(&issueNumber)
+		before, err := client.GetIssue(repo, issueNumber)
 		if err != nil {
-			fmt.Println("Invalid input:", err)
 			return err
 		}
+		current := workflow.StatusOf(before)
 
 		var status string
-		fmt.Print("Enter status (done, doing, will-do): ")
-		_, err = fmt.Scan(&status)
-		if err != nil || (status != "done" && status != "doing" && status != "will-do") {
-			fmt.Println("Invalid status:", err)
-			return err
+		if len(args) > 1 {
+			status = args[1]
+		} else {
+			if !isInteractive() {
+				return errMissingInput("a status")
+			}
+			status, err = promptLine(fmt.Sprintf("Enter status (%s): ", strings.Join(workflow.Transitions(current), ", ")))
+			if err != nil {
+				return fmt.Errorf("invalid input: %v", err)
+			}
 		}
 
-		/*
-			statusLabel := gitops.NewLabel(status)
-			if statusLabel == nil {
-				fmt.Println("Error creating label.")
-				return nil
-			}
-		*/
-		err = gitops.ChangeIssueLabel(repoName, issueNumber, []string{status})
+		issue, err := client.SetStatus(repo, workflow, before, status)
 		if err != nil {
-			fmt.Println("Error updating issue:", err)
-			return err
+			return fmt.Errorf("error updating issue: %w", err)
 		}
+		recordIssueChange(config.OpStatus, repo, before)
 
-		fmt.Printf("To-do item associated with issue #%d marked as %s.\n", issueNumber, status)
-		return nil
+		return printResult(newTaskOutput(issue, workflow), func() {
+			fmt.Printf("To-do item associated with issue #%d marked as %s.\n", issueNumber, workflow.StatusOf(issue))
+		})
 	},
 }
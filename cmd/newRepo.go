@@ -1,60 +1,83 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
-	_ "path/filepath"
+	"path/filepath"
 	"strings"
 	"teriyake/go-git-it/config"
 	"teriyake/go-git-it/gitops"
 )
 
-var isPrivate bool
+var (
+	isPrivate bool
+	repoOrg   string
+)
 
 var newRepoCmd = &cobra.Command{
-	Use:   "new-repo",
+	Use:   "new-repo [name]",
 	Short: "Create a new to-do repo",
-	Long:  `This command creates a new to-do repo remotely and clones it into .go-git-it/repos.`,
+	Long: `This command creates a new to-do repo remotely, with the status labels, and clones it into .go-git-it/repos.
+If no name is given, it is prompted for; an empty answer uses the name of the current directory.
+With --org, the repo is created in that organization so the whole team can share it.
+Example: new-repo sprint-todo --org my-team`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Enter the name for the new to-do repo:")
-		reader := bufio.NewReader(os.Stdin)
-		path, _ := reader.ReadString('\n')
-		path = strings.TrimSpace(path)
-		if path == "" {
-			path, _ = os.Getwd()
+		var path string
+		if len(args) > 0 {
+			path = strings.TrimSpace(args[0])
 		} else {
-			/*
-				var err error
-				path, err = filepath.Abs(path)
-				if err != nil {
-					return fmt.Errorf("failed to resolve absolute path: %v", err)
-				}
-			*/
-			path = path
+			if !isInteractive() {
+				return errMissingInput("a repo name")
+			}
+			var err error
+			path, err = promptLine("Enter the name for the new to-do repo:\n")
+			if err != nil {
+				return fmt.Errorf("invalid input: %v", err)
+			}
+		}
+		if path == "" {
+			wd, _ := os.Getwd()
+			path = filepath.Base(wd)
 		}
 
-		if err := gitops.CreateNewRepo(path, isPrivate); err != nil {
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
+		}
+		owner := client.Username
+		if repoOrg != "" {
+			owner = repoOrg
+		}
+		repo, err := config.ParseRepoRef(path, owner)
+		if err != nil {
 			return err
 		}
+		if err := client.CreateNewRepo(repo, isPrivate); err != nil {
+			return err
+		}
+		if _, err := client.SyncStatusLabels(repo, gitops.DefaultWorkflow(), false); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to create the status labels with %v\nRun 'ggi labels sync' to retry.\n", err)
+		}
 
 		profile, err := config.LoadUserProfile()
 		if err != nil {
 			return fmt.Errorf("failed to load user profile: %v", err)
 		}
-		profile.AddRepo(path)
-		profile.SetCurrentRepo(path)
+		profile.AddRepo(repo)
+		profile.SetCurrentRepo(repo)
 		if err := profile.Save(); err != nil {
 			return fmt.Errorf("failed to save user profile: %v", err)
 		}
 
-		fmt.Printf("New to-do repo initialized at %s\n", path)
-		fmt.Printf("Current to-do repo: %s\n", profile.GetCurrentRepo())
+		fmt.Fprintf(infoOut(), "New to-do repo %s initialized at %s\n", repo, client.LocalPath(repo))
+		fmt.Fprintf(infoOut(), "Current to-do repo: %s\n", profile.GetCurrentRepo())
 		return nil
 	},
 }
 
 func init() {
 	newRepoCmd.Flags().BoolVarP(&isPrivate, "private", "p", false, "Make the new repo private")
+	newRepoCmd.Flags().StringVar(&repoOrg, "org", "", "Create the repo in this organization instead of your account")
 }
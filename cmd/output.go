@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"time"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+var outputFormat string
+
+// The types below are the documented schema for --output json/yaml (see the
+// README). Add fields freely, but do not rename or remove existing ones.
+
+type TaskOutput struct {
+	Number    int        `json:"number" yaml:"number"`
+	Title     string     `json:"title" yaml:"title"`
+	State     string     `json:"state" yaml:"state"`
+	Status    string     `json:"status" yaml:"status"`
+	Labels    []string   `json:"labels" yaml:"labels"`
+	Assignees []string   `json:"assignees" yaml:"assignees"`
+	Priority  string     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Due       string     `json:"due,omitempty" yaml:"due,omitempty"`
+	URL       string     `json:"url,omitempty" yaml:"url,omitempty"`
+	File      string     `json:"file,omitempty" yaml:"file,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty" yaml:"closed_at,omitempty"`
+}
+
+type ProfileOutput struct {
+	Profile       string   `json:"profile" yaml:"profile"`
+	Username      string   `json:"username" yaml:"username"`
+	Host          string   `json:"host" yaml:"host"`
+	Authenticated bool     `json:"authenticated" yaml:"authenticated"`
+	Repos         []string `json:"repos" yaml:"repos"`
+	CurrentRepo   string   `json:"current_repo" yaml:"current_repo"`
+}
+
+type ProfileEntryOutput struct {
+	Name     string `json:"name" yaml:"name"`
+	Active   bool   `json:"active" yaml:"active"`
+	Username string `json:"username" yaml:"username"`
+	Host     string `json:"host" yaml:"host"`
+}
+
+type SyncOutput struct {
+	Added    []string          `json:"added" yaml:"added"`
+	Cloned   []string          `json:"cloned" yaml:"cloned"`
+	Updated  []string          `json:"updated" yaml:"updated"`
+	Stale    []string          `json:"stale" yaml:"stale"`
+	Orphaned []string          `json:"orphaned" yaml:"orphaned"`
+	Failed   map[string]string `json:"failed" yaml:"failed"`
+}
+
+type LabelSyncOutput struct {
+	Name   string   `json:"name" yaml:"name"`
+	Action string   `json:"action" yaml:"action"`
+	Drift  []string `json:"drift" yaml:"drift"`
+}
+
+type UserOutput struct {
+	Login       string `json:"login" yaml:"login"`
+	Host        string `json:"host" yaml:"host"`
+	TokenSource string `json:"token_source" yaml:"token_source"`
+}
+
+// newTaskOutput describes issue, with its status taken from workflow. A nil
+// workflow leaves the status empty.
+func newTaskOutput(issue *gitops.Issue, workflow *gitops.Workflow) TaskOutput {
+	t := TaskOutput{
+		Number:    issue.Number,
+		Title:     issue.Title,
+		State:     issue.State,
+		Labels:    []string{},
+		Assignees: issue.AssigneeLogins(),
+		Priority:  issue.Priority(),
+		Due:       formatDue(issue.DueOn()),
+		URL:       issue.HTMLURL,
+		File:      issue.Meta()["file"],
+		ClosedAt:  issue.ClosedAt,
+	}
+	if workflow != nil {
+		t.Status = workflow.StatusOf(issue)
+	}
+	for _, l := range issue.Labels {
+		t.Labels = append(t.Labels, l.Name)
+	}
+	if !issue.CreatedAt.IsZero() {
+		created := issue.CreatedAt
+		t.CreatedAt = &created
+	}
+	return t
+}
+
+func newTaskOutputs(issues []gitops.Issue, workflow *gitops.Workflow) []TaskOutput {
+	tasks := make([]TaskOutput, 0, len(issues))
+	for i := range issues {
+		tasks = append(tasks, newTaskOutput(&issues[i], workflow))
+	}
+	return tasks
+}
+
+func newProfileOutput(profile *config.UserProfile, authenticated bool) ProfileOutput {
+	repos := []string{}
+	for _, repo := range profile.ListRepos() {
+		repos = append(repos, repo.String())
+	}
+	return ProfileOutput{
+		Profile:       profile.Name(),
+		Username:      profile.GetUsername(),
+		Host:          profile.GetHost(),
+		Authenticated: authenticated,
+		Repos:         repos,
+		CurrentRepo:   profile.GetCurrentRepo().String(),
+	}
+}
+
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q, expected one of text, json, yaml", outputFormat)
+}
+
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// infoOut is where human-oriented progress messages and prompts go. They are
+// moved to stderr when structured output is requested so stdout stays
+// machine-readable.
+func infoOut() io.Writer {
+	if structuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// printResult writes v to stdout in the selected structured format, or calls
+// text to print the human-readable form.
+func printResult(v interface{}, text func()) error {
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	text()
+	return nil
+}
+
+// printTask prints issue with printResult. Only structured output shows the
+// status, so the workflow of repo is loaded just for that.
+func printTask(client *gitops.Client, repo config.RepoRef, issue *gitops.Issue, text func()) error {
+	var workflow *gitops.Workflow
+	if structuredOutput() {
+		var err error
+		if workflow, err = loadWorkflow(client, repo); err != nil {
+			return err
+		}
+	}
+	return printResult(newTaskOutput(issue, workflow), text)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var clearPriority bool
+
+var priorityCmd = &cobra.Command{
+	Use:   "priority [issue number] [priority]",
+	Short: "Change or clear the priority of a task",
+	Long: `Change the priority of an existing task, or remove it with --clear.
+Priorities are P0 (most urgent) to P3; urgent, high, medium and low are accepted as P0, P1, P2 and P3.
+Examples: priority 2 P1
+          priority 2 high
+          priority 2 --clear`,
+	Args: taskFieldArgs(&clearPriority),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var priority string
+		if !clearPriority {
+			var err error
+			if priority, err = gitops.ParsePriority(args[1]); err != nil {
+				return err
+			}
+		}
+
+		return updateTask(args[0], func(client *gitops.Client, repo config.RepoRef, issue *gitops.Issue) (*gitops.Issue, error) {
+			previous := issue.Priority()
+			issue, err := client.SetIssuePriority(repo, issue, priority)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set priority with %w", err)
+			}
+			recordOperation(config.Operation{Kind: config.OpPriority, Repo: repo, Issue: issue.Number, Value: previous})
+			return issue, nil
+		}, func(issue *gitops.Issue) {
+			if priority == "" {
+				fmt.Printf("Priority of issue #%d cleared.\n", issue.Number)
+			} else {
+				fmt.Printf("Priority of issue #%d set to %s.\n", issue.Number, priority)
+			}
+		})
+	},
+}
+
+func init() {
+	priorityCmd.Flags().BoolVar(&clearPriority, "clear", false, "Remove the priority instead of setting one")
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
+)
+
+var (
+	profileFlag string
+	profileHost string
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named profiles",
+	Long: `Manage named profiles, e.g. for a personal and a work GitHub account.
+Each profile has its own host, token, to-do repos and current repo. Select one for a single command with --profile,
+or for all future commands with 'profile use'. $GGI_PROFILE also selects a profile.
+Example: profile add work --host ghe.example.com && ggi --profile work login`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := config.ListProfiles()
+		if err != nil {
+			return fmt.Errorf("failed to list profiles with %v", err)
+		}
+
+		active := config.ActiveProfile()
+		entries := make([]ProfileEntryOutput, 0, len(names))
+		for _, name := range names {
+			profile, err := config.LoadProfile(name)
+			if err != nil {
+				return fmt.Errorf("failed to load profile %s with %v", name, err)
+			}
+			entries = append(entries, ProfileEntryOutput{
+				Name:     name,
+				Active:   name == active,
+				Username: profile.GetUsername(),
+				Host:     profile.GetHost(),
+			})
+		}
+
+		return printResult(entries, func() {
+			for _, e := range entries {
+				marker := " "
+				if e.Active {
+					marker = "*"
+				}
+				fmt.Printf("%s %s\t%s\t%s\n", marker, e.Name, e.Host, orDash(e.Username))
+			}
+		})
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the active one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.UseProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(infoOut(), "Now using profile %s.\n", args[0])
+		return nil
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a new profile",
+	Long: `Create a new, empty profile. Run 'ggi --profile <name> login' afterwards to sign in with it.
+Example: profile add work --host ghe.example.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := config.AddProfile(args[0], profileHost); err != nil {
+			return err
+		}
+		fmt.Fprintf(infoOut(), "Profile %s created. Run 'ggi --profile %s login' to sign in.\n", args[0], args[0])
+		return nil
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Delete a profile",
+	Long:  `Delete a profile along with its stored token and its local clones. The remote repos are left untouched.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !config.ProfileExists(args[0]) {
+			return fmt.Errorf("profile %q does not exist", args[0])
+		}
+		ok, err := confirm(fmt.Sprintf("Are you sure you want to delete profile %s?", args[0]))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(infoOut(), "Deletion cancelled.")
+			return nil
+		}
+
+		if err := config.RemoveProfile(args[0]); err != nil {
+			return fmt.Errorf("failed to delete profile with %w", err)
+		}
+		fmt.Fprintf(infoOut(), "Profile %s has been deleted.\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	profileAddCmd.Flags().StringVar(&profileHost, "host", config.DefaultHost, "GitHub hostname the profile signs in to")
+
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"golang.org/x/term"
+	"os"
+	"strconv"
+	"strings"
+	"teriyake/go-git-it/config"
+)
+
+var assumeYes bool
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// isInteractive reports whether stdin is a terminal, i.e. whether it is
+// reasonable to prompt the user for missing input.
+func isInteractive() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func errMissingInput(what string) error {
+	return fmt.Errorf("%s is required when not running in a terminal", what)
+}
+
+func promptLine(label string) (string, error) {
+	fmt.Fprint(infoOut(), label)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptChoice prints options as a numbered list and returns the index of
+// the one the user picked.
+func promptChoice(header string, options []string) (int, error) {
+	fmt.Fprintln(infoOut(), header)
+	for i, option := range options {
+		fmt.Fprintf(infoOut(), "%d. %s\n", i+1, option)
+	}
+
+	choice, err := promptLine("Enter number: ")
+	if err != nil {
+		return 0, err
+	}
+	index, err := strconv.Atoi(choice)
+	if err != nil || index < 1 || index > len(options) {
+		return 0, fmt.Errorf("invalid selection, please enter a number between 1 and %d", len(options))
+	}
+	return index - 1, nil
+}
+
+// confirm asks a yes/no question. It succeeds without asking when --yes was
+// given and refuses to guess when there is no terminal to ask on.
+func confirm(question string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !isInteractive() {
+		return false, errors.New("refusing to continue without confirmation, pass --yes to skip it")
+	}
+
+	answer, err := promptLine(question + " [y/N]: ")
+	if err != nil {
+		return false, nil
+	}
+	return strings.ToLower(answer) == "y", nil
+}
+
+// promptPassphrase reads the passphrase for the encrypted-file credential
+// store without echoing it. $GGI_PASSPHRASE takes precedence so scripts can
+// unlock the store too.
+func promptPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv("GGI_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	if !isInteractive() {
+		return "", errors.New("the token is encrypted, set $GGI_PASSPHRASE to unlock it when not running in a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase with %v", err)
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("the passphrase cannot be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase with %v", err)
+		}
+		if string(again) != string(passphrase) {
+			return "", errors.New("the passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+func init() {
+	config.PassphraseFunc = promptPassphrase
+}
+
+func parseIssueNumber(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid issue number %q", s)
+	}
+	return n, nil
+}
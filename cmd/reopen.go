@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var reopenStatus string
+
+var reopenCmd = &cobra.Command{
+	Use:   "reopen [issue number]",
+	Short: "Reopen a closed to-do item",
+	Long: `This command reopens the closed issue of a to-do item in the current to-do repo.
+A task in a closing status like "done" is moved to the first open status the workflow allows, or to --status.
+If no issue number is given, it lists the closed issues and the user will select one to reopen.
+Example: reopen 2 --status doing`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, repo, err := currentRepoClient()
+		if err != nil {
+			return err
+		}
+
+		var issueNumber int
+		if len(args) > 0 {
+			issueNumber, err = parseIssueNumber(args[0])
+			if err != nil {
+				return err
+			}
+		} else {
+			if !isInteractive() {
+				return errMissingInput("an issue number")
+			}
+			issueNumber, err = selectIssue(client, repo, "closed", "Select a closed to-do item by number:")
+			if err != nil {
+				return err
+			}
+			if issueNumber == 0 {
+				return printResult([]TaskOutput{}, func() {})
+			}
+		}
+
+		workflow, err := loadWorkflow(client, repo)
+		if err != nil {
+			return err
+		}
+		before, err := client.GetIssue(repo, issueNumber)
+		if err != nil {
+			return err
+		}
+		if before.State != "closed" {
+			return fmt.Errorf("issue #%d is not closed", issueNumber)
+		}
+
+		status := reopenStatus
+		if current := workflow.State(workflow.StatusOf(before)); status == "" && current != nil && current.Closes {
+			status = workflow.ReopenState(current.Name)
+			if status == "" {
+				return fmt.Errorf("the workflow of %s does not allow reopening a task in %s", repo, current.Name)
+			}
+		}
+		if status != "" && workflow.State(status) != nil && workflow.State(status).Closes {
+			return fmt.Errorf("%s closes the issue, pick an open status", status)
+		}
+
+		var issue *gitops.Issue
+		if status != "" {
+			issue, err = client.SetStatus(repo, workflow, before, status)
+		} else {
+			issue, err = client.ReopenIssue(repo, issueNumber)
+		}
+		if err != nil {
+			return fmt.Errorf("error reopening issue: %w", err)
+		}
+		recordIssueChange(config.OpReopen, repo, before)
+
+		return printResult(newTaskOutput(issue, workflow), func() {
+			fmt.Printf("To-do item associated with issue #%d reopened.\n", issueNumber)
+		})
+	},
+}
+
+func init() {
+	reopenCmd.Flags().StringVarP(&reopenStatus, "status", "s", "", "Status to move the task to")
+}
@@ -1,11 +1,8 @@
 package cmd
 
 import (
-	"fmt"
 	"github.com/spf13/cobra"
-	"os"
 	"teriyake/go-git-it/config"
-	"teriyake/go-git-it/gitops"
 )
 
 var rootCmd = &cobra.Command{
@@ -18,6 +15,7 @@ set deadlines using issues, collaborate using pull requests, and pause tasks usi
 }
 
 func Execute() error {
+	rootCmd.SilenceUsage = true
 	return rootCmd.Execute()
 }
 
@@ -26,66 +24,39 @@ func init() {
 
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(newRepoCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(labelCmd)
+	rootCmd.AddCommand(labelsCmd)
+	rootCmd.AddCommand(workflowCmd)
 	rootCmd.AddCommand(chooseRepoCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
 	rootCmd.AddCommand(whoamiCmd)
 	rootCmd.AddCommand(delRepoCmd)
 	rootCmd.AddCommand(markCmd)
 	rootCmd.AddCommand(doneCmd)
+	rootCmd.AddCommand(reopenCmd)
+	rootCmd.AddCommand(undoCmd)
 	rootCmd.AddCommand(delTaskCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(deadlineCmd)
+	rootCmd.AddCommand(priorityCmd)
+	rootCmd.AddCommand(profileCmd)
 	// more cmds...
 
-	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		profile, err := config.LoadUserProfile()
-		if err != nil {
-			return fmt.Errorf("failed to load user profile: %v", err)
-		}
-
-		if len(profile.ToDoRepos) == 0 {
-			fmt.Println("No existing to-do repos found. Please use 'new-repo' command to create one.")
-			return nil
-		}
-
-		fmt.Println("Select a to-do repo to work with by entering the corresponding number:")
-		for i, repo := range profile.ToDoRepos {
-			fmt.Printf("%d. %s\n", i+1, repo)
-		}
-
-		var index int
-		fmt.Print("Enter number: ")
-		_, err = fmt.Scan(&index)
-		if err != nil || index < 1 || index > len(profile.ToDoRepos) {
-			fmt.Println("Invalid selection. Please restart the command and select a valid number.")
-			os.Exit(1)
-		}
-
-		selectedRepo := profile.ToDoRepos[index-1]
-		fmt.Printf("Setting current directory to: %s\n", selectedRepo)
-
-		if err := os.Chdir(selectedRepo); err != nil {
-			fmt.Errorf("failed to change directory: %v", err)
-			os.Exit(1)
-		}
-
-		return nil
-	}
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompts")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text, json or yaml")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Profile to use instead of the active one")
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		if !gitops.IsGitRepo() {
-			profile, err := config.LoadUserProfile()
-			if err != nil {
-				return fmt.Errorf("failed to load user profile: %v", err)
-			}
-			if len(profile.ToDoRepos) == 0 {
-				fmt.Println("No existing to-do repos found. Please create a new to-do repo.")
-				return nil
-				// handle creating new repo
-			}
-			fmt.Println("Select a to-do repo to work with:")
-			for i, repo := range profile.ToDoRepos {
-				fmt.Printf("%d. %s\n", i+1, repo)
-			}
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if profileFlag != "" {
+			return config.SetActiveProfile(profileFlag)
 		}
 		return nil
 	}
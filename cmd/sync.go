@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var pruneRepos bool
+
+var syncCmd = &cobra.Command{
+	Use:     "sync",
+	Aliases: []string{"clone"},
+	Short:   "Restore and update the local clones of your to-do repos",
+	Long: `Bring your profile and local clones in line with GitHub, e.g. on a new machine.
+To-do repos on GitHub created by ggi (tagged with the go-git-it topic or description) are added to your profile.
+Repos in your profile are cloned if they are missing locally and updated with 'git pull --rebase' otherwise.
+Repos in your profile that no longer exist on GitHub are reported as stale (and removed from the profile with --prune),
+and local clones that are not in your profile are reported as orphaned.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, err := config.LoadUserProfile()
+		if err != nil {
+			return fmt.Errorf("failed to load user profile with %v", err)
+		}
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
+		}
+
+		out := SyncOutput{Added: []string{}, Cloned: []string{}, Updated: []string{}, Stale: []string{}, Orphaned: []string{}, Failed: map[string]string{}}
+
+		remote, err := client.ListRepos()
+		if err != nil {
+			return fmt.Errorf("failed to list repos with %w", err)
+		}
+		for i := range remote {
+			if !remote[i].IsToDoRepo() || hasRepo(profile.ListRepos(), remote[i].Ref()) {
+				continue
+			}
+			profile.AddRepo(remote[i].Ref())
+			out.Added = append(out.Added, remote[i].Ref().String())
+		}
+
+		for _, repo := range profile.ListRepos() {
+			_, err := client.GetRepo(repo)
+			var notFound *gitops.NotFoundError
+			if errors.As(err, &notFound) {
+				out.Stale = append(out.Stale, repo.String())
+				continue
+			}
+			if err != nil {
+				out.Failed[repo.String()] = err.Error()
+				continue
+			}
+
+			path := client.LocalPath(repo)
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				fmt.Fprintf(infoOut(), "Cloning %s...\n", repo)
+				if _, err := client.CloneRepo(repo); err != nil {
+					out.Failed[repo.String()] = err.Error()
+					continue
+				}
+				out.Cloned = append(out.Cloned, repo.String())
+				continue
+			}
+
+			fmt.Fprintf(infoOut(), "Updating %s...\n", repo)
+			if err := gitops.PullRebase(path); err != nil {
+				out.Failed[repo.String()] = err.Error()
+				continue
+			}
+			out.Updated = append(out.Updated, repo.String())
+		}
+
+		if pruneRepos {
+			for _, name := range out.Stale {
+				repo, _ := config.ParseRepoRef(name, "")
+				profile.RemoveRepo(repo)
+			}
+		}
+		if err := profile.Save(); err != nil {
+			return fmt.Errorf("failed to save user profile with %v", err)
+		}
+
+		clones, err := config.LocalClones(client.Username)
+		if err != nil {
+			return fmt.Errorf("failed to list local repos with %v", err)
+		}
+		for _, clone := range clones {
+			if !hasRepo(profile.ListRepos(), clone) && !(pruneRepos && contains(out.Stale, clone.String())) {
+				out.Orphaned = append(out.Orphaned, clone.String())
+			}
+		}
+
+		err = printResult(out, func() {
+			printRepoList("Added to your profile", out.Added)
+			printRepoList("Cloned", out.Cloned)
+			printRepoList("Updated", out.Updated)
+			if pruneRepos {
+				printRepoList("Removed stale repos (no longer on GitHub)", out.Stale)
+			} else {
+				printRepoList("Stale repos (no longer on GitHub, remove with --prune)", out.Stale)
+			}
+			printRepoList("Orphaned local clones (not in your profile)", out.Orphaned)
+			for repo, reason := range out.Failed {
+				fmt.Printf("Failed to sync %s: %s\n", repo, reason)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Failed) > 0 {
+			return fmt.Errorf("%d repo(s) could not be synced", len(out.Failed))
+		}
+		return nil
+	},
+}
+
+func hasRepo(repos []config.RepoRef, repo config.RepoRef) bool {
+	for _, r := range repos {
+		if r.Equal(repo) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func printRepoList(header string, repos []string) {
+	if len(repos) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", header)
+	for _, repo := range repos {
+		fmt.Printf("- %s\n", repo)
+	}
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&pruneRepos, "prune", false, "Remove stale repos from your profile")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+// taskFieldArgs accepts "<issue number> <value>" for commands that set a
+// field of a task, or only the issue number when the field is cleared.
+func taskFieldArgs(clear *bool) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if *clear {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	}
+}
+
+// updateTask looks up the task numbered issueArg in the current repo, changes
+// it with update and prints the result.
+func updateTask(issueArg string, update func(*gitops.Client, config.RepoRef, *gitops.Issue) (*gitops.Issue, error), text func(issue *gitops.Issue)) error {
+	issueNumber, err := parseIssueNumber(issueArg)
+	if err != nil {
+		return err
+	}
+	client, repo, err := currentRepoClient()
+	if err != nil {
+		return err
+	}
+
+	issue, err := client.GetIssue(repo, issueNumber)
+	if err != nil {
+		return err
+	}
+	issue, err = update(client, repo, issue)
+	if err != nil {
+		return err
+	}
+	return printTask(client, repo, issue, func() { text(issue) })
+}
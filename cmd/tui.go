@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"teriyake/go-git-it/tui"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Open the interactive terminal UI",
+	Long: `Open a full-screen terminal UI that lists your to-do repos and shows the tasks of the selected repo as a
+will-do / doing / done board. Cards can be moved between columns, closed, added and given deadlines with
+keyboard shortcuts, and the board refreshes in the background.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isInteractive() {
+			return fmt.Errorf("the TUI needs to be run in a terminal")
+		}
+
+		profile, err := config.LoadUserProfile()
+		if err != nil {
+			return fmt.Errorf("failed to load user profile with %v", err)
+		}
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
+		}
+		return tui.Run(client, profile)
+	},
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"time"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last change made to a task",
+	Long: `Reverse the last change ggi made to a task: adding it, closing or reopening it, changing its status, labels, deadline or priority, or deleting its task file.
+Issue changes are undone by restoring the issue's previous state, labels, deadline or priority, and deleted task files by pushing a revert commit.
+Undoing an added task closes its issue as not planned and reverts the commit of its task file.
+ggi keeps the last 50 changes of each profile, so running undo repeatedly steps further back. Pass --yes to skip the confirmation prompt.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		op, err := config.LastOperation()
+		if err != nil {
+			return fmt.Errorf("failed to read the undo journal with %v", err)
+		}
+		if op == nil {
+			fmt.Fprintln(infoOut(), "Nothing to undo.")
+			return nil
+		}
+
+		ok, err := confirm(fmt.Sprintf("Undo %s?", describeOperation(op)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(infoOut(), "Undo cancelled.")
+			return nil
+		}
+
+		client, err := gitops.NewClientFromProfile()
+		if err != nil {
+			return err
+		}
+
+		if op.Kind == config.OpDeleteTask {
+			if err := gitops.RevertCommit(client.LocalPath(op.Repo), op.Commit); err != nil {
+				return fmt.Errorf("failed to restore %s with %w", op.File, err)
+			}
+			if err := config.PopOperation(); err != nil {
+				return fmt.Errorf("failed to update the undo journal with %v", err)
+			}
+			fmt.Fprintf(infoOut(), "Restored task file %s in %s.\n", op.File, op.Repo)
+			return nil
+		}
+
+		issue, err := undoIssueChange(client, op)
+		if err != nil {
+			return err
+		}
+		if err := config.PopOperation(); err != nil {
+			return fmt.Errorf("failed to update the undo journal with %v", err)
+		}
+
+		return printTask(client, op.Repo, issue, func() {
+			fmt.Printf("Undid %s.\n", describeOperation(op))
+		})
+	},
+}
+
+// undoIssueChange reverses op, a change to an issue, and returns the issue as
+// it is afterwards.
+func undoIssueChange(client *gitops.Client, op *config.Operation) (*gitops.Issue, error) {
+	switch op.Kind {
+	case config.OpAdd:
+		if op.Commit != "" {
+			if err := gitops.RevertCommit(client.LocalPath(op.Repo), op.Commit); err != nil {
+				return nil, fmt.Errorf("failed to remove %s with %w", op.File, err)
+			}
+		}
+		issue, err := client.DiscardIssue(op.Repo, op.Issue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to close issue #%d with %w", op.Issue, err)
+		}
+		return issue, nil
+	case config.OpDeadline, config.OpPriority:
+		issue, err := client.GetIssue(op.Repo, op.Issue)
+		if err != nil {
+			return nil, err
+		}
+		if op.Kind == config.OpPriority {
+			issue, err = client.SetIssuePriority(op.Repo, issue, op.Value)
+		} else {
+			var due *time.Time
+			if op.Value != "" {
+				parsed, err := gitops.ParseDeadline(op.Value)
+				if err != nil {
+					return nil, err
+				}
+				due = &parsed
+			}
+			issue, err = client.SetIssueDeadline(op.Repo, issue, due)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore issue #%d with %w", op.Issue, err)
+		}
+		return issue, nil
+	}
+
+	issue, err := client.RestoreIssue(op.Repo, op.Issue, op.State, op.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore issue #%d with %w", op.Issue, err)
+	}
+	return issue, nil
+}
+
+func describeOperation(op *config.Operation) string {
+	switch op.Kind {
+	case config.OpClose:
+		return fmt.Sprintf("closing #%d in %s", op.Issue, op.Repo)
+	case config.OpReopen:
+		return fmt.Sprintf("reopening #%d in %s", op.Issue, op.Repo)
+	case config.OpStatus:
+		return fmt.Sprintf("the status change of #%d in %s", op.Issue, op.Repo)
+	case config.OpLabel:
+		return fmt.Sprintf("the label change of #%d in %s", op.Issue, op.Repo)
+	case config.OpDeleteTask:
+		return fmt.Sprintf("deleting task file %s in %s", op.File, op.Repo)
+	case config.OpDeadline:
+		return fmt.Sprintf("the deadline change of #%d in %s", op.Issue, op.Repo)
+	case config.OpPriority:
+		return fmt.Sprintf("the priority change of #%d in %s", op.Issue, op.Repo)
+	case config.OpAdd:
+		return fmt.Sprintf("adding #%d in %s", op.Issue, op.Repo)
+	}
+	return op.Kind
+}
+
+// recordIssueChange adds a change to before, the issue as it was, to the undo
+// journal. A failure only warns since the change itself went through.
+func recordIssueChange(kind string, repo config.RepoRef, before *gitops.Issue) {
+	recordOperation(config.Operation{
+		Kind:   kind,
+		Repo:   repo,
+		Issue:  before.Number,
+		State:  before.State,
+		Labels: before.LabelNames(),
+	})
+}
+
+// recordOperation adds op to the undo journal, only warning on failure.
+func recordOperation(op config.Operation) {
+	if err := config.RecordOperation(op); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the change for undo with %v\n", err)
+	}
+}
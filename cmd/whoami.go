@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"teriyake/go-git-it/config"
 	"teriyake/go-git-it/gitauth"
 )
 
@@ -10,9 +11,27 @@ var whoamiCmd = &cobra.Command{
 	Use:   "whoami",
 	Short: "Verify your Github auth status",
 	Long:  `Verify whether you have successfully authorized ggi to perform necessary Git operations via Github API calls.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("Authorizing...\n")
-		me := gitauth.Whoami()
-		fmt.Printf("You are %s\n", me)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintf(infoOut(), "Authorizing...\n")
+		me, err := gitauth.Whoami()
+		if err != nil {
+			return err
+		}
+		profile, err := config.LoadUserProfile()
+		if err != nil {
+			return fmt.Errorf("failed to load user profile with %v", err)
+		}
+		// App installation tokens are minted by the client rather than
+		// resolved from a credential store.
+		source := "github app installation"
+		if !profile.UsesApp() {
+			_, source, err = config.ResolveToken()
+			if err != nil {
+				return err
+			}
+		}
+		return printResult(UserOutput{Login: me, Host: profile.GetHost(), TokenSource: source}, func() {
+			fmt.Printf("You are %s (token from %s)\n", me, source)
+		})
 	},
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+)
+
+var workflowCmd = &cobra.Command{
+	Use:   "workflow",
+	Short: "Show or change the status workflow of the current to-do repo",
+	Long: `Each to-do repo has a workflow: the statuses its tasks can have, their label colors, which statuses a task
+may move to from each status, and which statuses close the issue. It is kept in ` + gitops.WorkflowPath + ` in the repo;
+repos without one use "will-do", "doing" and "done", where "done" closes the issue.
+Example: workflow show -o json > workflow.json, edit it, then workflow set workflow.json`,
+}
+
+var workflowShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the workflow of the current to-do repo",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, repo, err := currentRepoClient()
+		if err != nil {
+			return err
+		}
+		workflow, err := loadWorkflow(client, repo)
+		if err != nil {
+			return err
+		}
+
+		return printResult(workflow, func() {
+			for _, s := range workflow.States {
+				closes := ""
+				if s.Closes {
+					closes = " (closes the issue)"
+				}
+				fmt.Printf("%s%s -> %s\n", s.Name, closes, strings.Join(workflow.Transitions(s.Name), ", "))
+			}
+		})
+	},
+}
+
+var workflowSetCmd = &cobra.Command{
+	Use:   "set <file>",
+	Short: "Replace the workflow of the current to-do repo",
+	Long: `Validate the workflow in file, commit and push it to the current to-do repo, and create or update the status labels to match.
+Tasks whose status is no longer part of the workflow keep their label and can be moved to any status.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read workflow with %v", err)
+		}
+		workflow, err := gitops.ParseWorkflow(data)
+		if err != nil {
+			return err
+		}
+
+		client, repo, err := currentRepoClient()
+		if err != nil {
+			return err
+		}
+		repoPath := client.LocalPath(repo)
+		if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+			return fmt.Errorf("%s is not cloned at %s, run 'sync' first", repo, repoPath)
+		}
+		if err := gitops.PullRebase(repoPath); err != nil {
+			return err
+		}
+		if err := gitops.WriteWorkflow(repoPath, workflow); err != nil {
+			return fmt.Errorf("failed to save workflow with %w", err)
+		}
+		fmt.Fprintf(infoOut(), "Workflow of %s updated.\n", repo)
+
+		results, err := client.SyncStatusLabels(repo, workflow, false)
+		if err != nil {
+			return fmt.Errorf("failed to update the status labels with %w, run 'labels sync' to retry", err)
+		}
+		for _, r := range results {
+			if r.Action != gitops.LabelInSync {
+				fmt.Fprintf(infoOut(), "Label %s %s.\n", r.Name, r.Action)
+			}
+		}
+		return nil
+	},
+}
+
+// currentRepoClient returns a client for the active profile along with its
+// current to-do repo.
+func currentRepoClient() (*gitops.Client, config.RepoRef, error) {
+	profile, err := config.LoadUserProfile()
+	if err != nil {
+		return nil, config.RepoRef{}, fmt.Errorf("failed to load user profile with %v", err)
+	}
+	repo := profile.GetCurrentRepo()
+	if repo.IsZero() {
+		return nil, config.RepoRef{}, fmt.Errorf("no current to-do repo, use 'choose-repo' to select one")
+	}
+	client, err := gitops.NewClientFromProfile()
+	if err != nil {
+		return nil, config.RepoRef{}, err
+	}
+	return client, repo, nil
+}
+
+func init() {
+	workflowCmd.AddCommand(workflowShowCmd)
+	workflowCmd.AddCommand(workflowSetCmd)
+}
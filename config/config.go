@@ -2,48 +2,123 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+const DefaultHost = "github.com"
+
 type UserProfile struct {
-	Username    string   `json:"username"`
-	ToDoRepos   []string `json:"to_do_repos"`
-	CurrentRepo string   `json:"current_repo"`
-}
+	Username string `json:"username"`
+	Host     string `json:"host,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// CredentialStore is where the token is kept. Profiles written before
+	// credential stores existed leave it empty; their plaintext token is moved
+	// to the keyring when they are loaded.
+	CredentialStore string `json:"credential_store,omitempty"`
+	// TokenHelper is a shell command that prints a token, e.g.
+	// "gh auth token --hostname github.com".
+	TokenHelper string `json:"token_helper,omitempty"`
+	// AppID, InstallationID and PrivateKeyPath switch the profile to
+	// authenticating as a GitHub App installation, e.g. a shared team bot.
+	AppID          string    `json:"app_id,omitempty"`
+	InstallationID int64     `json:"installation_id,omitempty"`
+	PrivateKeyPath string    `json:"private_key_path,omitempty"`
+	ToDoRepos      []RepoRef `json:"to_do_repos"`
+	CurrentRepo    RepoRef   `json:"current_repo"`
+	// AdoptedRepos are the ToDoRepos that existed before ggi used them, such
+	// as a team's project repo. ggi never deletes them on GitHub by itself.
+	AdoptedRepos []RepoRef `json:"adopted_repos,omitempty"`
 
-var (
-	profilePath = filepath.Join(os.Getenv("HOME"), ".go-git-it", "profile.json")
-	tokenPath   = filepath.Join(os.Getenv("HOME"), ".go-git-it", ".token")
-)
+	name string
+}
 
+// LoadUserProfile loads the active profile.
 func LoadUserProfile() (*UserProfile, error) {
-	var profile UserProfile
+	return LoadProfile(ActiveProfile())
+}
 
-	if _, err := os.Stat(profilePath); os.IsNotExist(err) {
-		return &profile, nil
+func LoadProfile(name string) (*UserProfile, error) {
+	if !ProfileExists(name) {
+		return nil, fmt.Errorf("profile %q does not exist, create it with 'profile add'", name)
 	}
+	profile := UserProfile{name: name}
+	profilePath := filepath.Join(ProfileDir(name), "profile.json")
 
 	data, err := ioutil.ReadFile(profilePath)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
-
-	if err := json.Unmarshal(data, &profile); err != nil {
-		return nil, err
+	if err == nil {
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return nil, err
+		}
+		profile.fillRepoOwners()
+	}
+	if profile.CredentialStore == "" {
+		profile.migrateLegacyToken()
 	}
 
 	return &profile, nil
 }
 
+// legacyTokenWarned keeps the warning about a plaintext token that could not
+// be migrated to once per run.
+var legacyTokenWarned bool
+
+// migrateLegacyToken moves the plaintext .token file of a profile written
+// before credential stores existed into the keyring. If that fails, the
+// profile keeps using the file and a warning is printed.
+func (p *UserProfile) migrateLegacyToken() {
+	legacy := &plainFileStore{path: filepath.Join(ProfileDir(p.Name()), ".token")}
+	token, err := legacy.Get()
+	if err != nil {
+		return
+	}
+
+	err = p.moveToken(token, legacy, StoreKeyring)
+	if err != nil && !legacyTokenWarned {
+		legacyTokenWarned = true
+		fmt.Fprintf(os.Stderr, "Warning: your token is stored in plain text in %s and could not be moved to the keyring: %v\nRun 'login --store encrypted-file' to protect it.\n", legacy.path, err)
+	}
+}
+
+func (p *UserProfile) moveToken(token string, from CredentialStore, kind string) error {
+	to, err := p.NewCredentialStore(kind)
+	if err != nil {
+		return err
+	}
+	if err := to.Set(token); err != nil {
+		return err
+	}
+	p.CredentialStore = kind
+	if err := p.Save(); err != nil {
+		p.CredentialStore = ""
+		to.Delete()
+		return fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return from.Delete()
+}
+
+// Name returns the name of the profile.
+func (p *UserProfile) Name() string {
+	if p.name == "" {
+		return DefaultProfile
+	}
+	return p.name
+}
+
 func (p *UserProfile) Save() error {
 	data, err := json.Marshal(p)
 	if err != nil {
 		return err
 	}
 
+	profilePath := filepath.Join(ProfileDir(p.Name()), "profile.json")
 	dir := filepath.Dir(profilePath)
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -54,54 +129,245 @@ func (p *UserProfile) Save() error {
 	return ioutil.WriteFile(profilePath, data, 0644)
 }
 
-func (p *UserProfile) AddRepo(repoPath string) {
+func (p *UserProfile) AddRepo(repo RepoRef) {
 	for _, r := range p.ToDoRepos {
-		if r == repoPath {
+		if r.Equal(repo) {
 			return
 		}
 	}
-	p.ToDoRepos = append(p.ToDoRepos, repoPath)
+	p.ToDoRepos = append(p.ToDoRepos, repo)
 }
 
-func (p *UserProfile) ListRepos() []string {
+func (p *UserProfile) ListRepos() []RepoRef {
 	return p.ToDoRepos
 }
 
-func (p *UserProfile) GetCurrentRepo() string {
+// FindRepo looks up one of the profile's repos given as "owner/name", or as
+// just "name" when that is unambiguous.
+func (p *UserProfile) FindRepo(s string) (RepoRef, error) {
+	var matches []RepoRef
+	for _, r := range p.ToDoRepos {
+		if strings.Contains(s, "/") {
+			if strings.EqualFold(r.String(), s) {
+				return r, nil
+			}
+		} else if strings.EqualFold(r.Name, s) {
+			matches = append(matches, r)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return RepoRef{}, fmt.Errorf("%s is not one of your to-do repos", s)
+	case 1:
+		return matches[0], nil
+	}
+	return RepoRef{}, fmt.Errorf("%s is ambiguous, use owner/name", s)
+}
+
+func (p *UserProfile) GetCurrentRepo() RepoRef {
 	return p.CurrentRepo
 }
 
-func (p *UserProfile) SetCurrentRepo(repo string) {
+func (p *UserProfile) SetCurrentRepo(repo RepoRef) {
 	p.CurrentRepo = repo
 }
 
-func (p *UserProfile) RemoveRepo(repo string) {
-	var updatedRepos []string
-	for _, r := range p.ToDoRepos {
-		if r != repo {
-			updatedRepos = append(updatedRepos, r)
+// AdoptRepo adds an existing repo to the profile, see AdoptedRepos.
+func (p *UserProfile) AdoptRepo(repo RepoRef) {
+	p.AddRepo(repo)
+	if !p.IsAdopted(repo) {
+		p.AdoptedRepos = append(p.AdoptedRepos, repo)
+	}
+}
+
+func (p *UserProfile) IsAdopted(repo RepoRef) bool {
+	for _, r := range p.AdoptedRepos {
+		if r.Equal(repo) {
+			return true
 		}
 	}
-	p.ToDoRepos = updatedRepos
+	return false
+}
+
+func (p *UserProfile) RemoveRepo(repo RepoRef) {
+	p.ToDoRepos = removeRepoRef(p.ToDoRepos, repo)
+	p.AdoptedRepos = removeRepoRef(p.AdoptedRepos, repo)
 
-	if p.CurrentRepo == repo {
-		p.CurrentRepo = ""
+	if p.CurrentRepo.Equal(repo) {
+		p.CurrentRepo = RepoRef{}
 	}
 }
 
-func GetToken() (string, error) {
-	token, err := ioutil.ReadFile(tokenPath)
-	if err != nil {
-		fmt.Println("You are not authorized. Run the `login` command.")
-		os.Exit(1)
+func removeRepoRef(repos []RepoRef, repo RepoRef) []RepoRef {
+	var updated []RepoRef
+	for _, r := range repos {
+		if !r.Equal(repo) {
+			updated = append(updated, r)
+		}
 	}
-	return string(token), nil
+	return updated
+}
+
+// LocalRepoPath is where the profile's clone of repo lives.
+func (p *UserProfile) LocalRepoPath(repo RepoRef) string {
+	return LocalRepoPath(repo, p.Username)
+}
+
+// fillRepoOwners sets the owner of repos stored by name only, which were
+// always owned by the profile's user.
+func (p *UserProfile) fillRepoOwners() {
+	if p.Username == "" {
+		return
+	}
+	for i := range p.ToDoRepos {
+		if p.ToDoRepos[i].Owner == "" {
+			p.ToDoRepos[i].Owner = p.Username
+		}
+	}
+	for i := range p.AdoptedRepos {
+		if p.AdoptedRepos[i].Owner == "" {
+			p.AdoptedRepos[i].Owner = p.Username
+		}
+	}
+	if !p.CurrentRepo.IsZero() && p.CurrentRepo.Owner == "" {
+		p.CurrentRepo.Owner = p.Username
+	}
+}
+
+// ErrNotAuthenticated is returned when no GitHub token is available.
+var ErrNotAuthenticated = errors.New("you are not authorized, run the `login` command")
+
+// ErrSessionExpired is returned when an expiring token can no longer be
+// refreshed because the refresh token has expired or been revoked.
+var ErrSessionExpired = errors.New("your session has expired, run the `login` command again")
+
+// GetToken returns the token ggi should authenticate with, see ResolveToken.
+func GetToken() (string, error) {
+	token, _, err := ResolveToken()
+	return token, err
 }
 
 func (p *UserProfile) SetUsername(u string) {
 	p.Username = u
+	p.fillRepoOwners()
 }
 
 func (p *UserProfile) GetUsername() string {
 	return p.Username
 }
+
+func (p *UserProfile) GetHost() string {
+	if p.Host == "" {
+		return DefaultHost
+	}
+	return p.Host
+}
+
+func (p *UserProfile) SetHost(h string) {
+	h = NormalizeHost(h)
+	if h == DefaultHost {
+		h = ""
+	}
+	p.Host = h
+}
+
+// GetCredentialStore returns where the profile's token is kept. Profiles
+// without a store still use the plaintext file if their token could not be
+// migrated.
+func (p *UserProfile) GetCredentialStore() string {
+	if p.CredentialStore == "" {
+		return StoreFile
+	}
+	return p.CredentialStore
+}
+
+func (p *UserProfile) SetCredentialStore(kind string) {
+	p.CredentialStore = kind
+}
+
+// TokenStore returns the credential store holding the profile's token.
+func (p *UserProfile) TokenStore() (CredentialStore, error) {
+	return p.NewCredentialStore(p.GetCredentialStore())
+}
+
+// NewCredentialStore returns the given kind of credential store (one of
+// CredentialStores) for the profile's token.
+func (p *UserProfile) NewCredentialStore(kind string) (CredentialStore, error) {
+	dir := ProfileDir(p.Name())
+	switch kind {
+	case StoreKeyring:
+		// The default profile keeps the key it had before named profiles.
+		account := p.GetHost()
+		if p.Name() != DefaultProfile {
+			account = p.Name() + ":" + account
+		}
+		return &keyringStore{account: account}, nil
+	case StoreEncryptedFile:
+		return sharedEncryptedStore(filepath.Join(dir, ".token.enc")), nil
+	case StoreFile:
+		return &plainFileStore{path: filepath.Join(dir, ".token")}, nil
+	}
+	return nil, fmt.Errorf("unknown credential store %q, expected one of %s", kind, strings.Join(CredentialStores, ", "))
+}
+
+func (p *UserProfile) GetTokenHelper() string {
+	return p.TokenHelper
+}
+
+func (p *UserProfile) SetTokenHelper(command string) {
+	p.TokenHelper = strings.TrimSpace(command)
+}
+
+// UsesApp reports whether the profile authenticates as a GitHub App
+// installation rather than as a user.
+func (p *UserProfile) UsesApp() bool {
+	return p.AppID != "" && p.InstallationID != 0
+}
+
+func (p *UserProfile) SetApp(appID string, installationID int64, keyPath string) {
+	p.AppID = appID
+	p.InstallationID = installationID
+	p.PrivateKeyPath = keyPath
+}
+
+func (p *UserProfile) GetClientID(fallback string) string {
+	if p.ClientID == "" {
+		return fallback
+	}
+	return p.ClientID
+}
+
+func (p *UserProfile) SetClientID(id string) {
+	p.ClientID = id
+}
+
+// NormalizeHost strips the scheme and any trailing path from a host given on
+// the command line, so "https://ghe.example.com/" becomes "ghe.example.com".
+func NormalizeHost(h string) string {
+	h = strings.TrimSpace(h)
+	h = strings.TrimPrefix(h, "https://")
+	h = strings.TrimPrefix(h, "http://")
+	if i := strings.Index(h, "/"); i >= 0 {
+		h = h[:i]
+	}
+	if h == "" || h == "api.github.com" {
+		return DefaultHost
+	}
+	return strings.ToLower(h)
+}
+
+// WebURL returns the base URL of the GitHub web UI for host, which also
+// serves the OAuth device flow endpoints and git remotes.
+func WebURL(host string) string {
+	return "https://" + NormalizeHost(host)
+}
+
+// APIBaseURL returns the REST API root for host. github.com is served from
+// api.github.com while GitHub Enterprise Server mounts it under /api/v3.
+func APIBaseURL(host string) string {
+	host = NormalizeHost(host)
+	if host == DefaultHost {
+		return "https://api.github.com"
+	}
+	return "https://" + host + "/api/v3"
+}
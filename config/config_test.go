@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"github.com/zalando/go-keyring"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLegacyToken(t *testing.T) {
+	dir := useTempConfigDir(t)
+	keyring.MockInit()
+	legacy := filepath.Join(dir, ".token")
+	if err := os.WriteFile(legacy, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := LoadUserProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profile.GetCredentialStore(); got != StoreKeyring {
+		t.Errorf("credential store = %s, want %s", got, StoreKeyring)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("the plaintext token is still there: %v", err)
+	}
+	if token, err := keyring.Get(keyringService, DefaultHost); err != nil || token != "secret" {
+		t.Errorf("keyring token = %q, %v, want secret", token, err)
+	}
+
+	// The migration is saved, so the next load finds the token in the
+	// keyring.
+	profile, err = LoadUserProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profile.GetCredentialStore(); got != StoreKeyring {
+		t.Errorf("credential store after reload = %s, want %s", got, StoreKeyring)
+	}
+}
+
+func TestMigrateLegacyTokenWithoutKeyring(t *testing.T) {
+	dir := useTempConfigDir(t)
+	keyring.MockInitWithError(errors.New("no keyring"))
+	legacy := filepath.Join(dir, ".token")
+	if err := os.WriteFile(legacy, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := LoadUserProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profile.GetCredentialStore(); got != StoreFile {
+		t.Errorf("credential store = %s, want %s", got, StoreFile)
+	}
+	store, err := profile.TokenStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := store.Get(); err != nil || token != "secret" {
+		t.Errorf("token = %q, %v, want the plaintext token to keep working", token, err)
+	}
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	StoreKeyring       = "keyring"
+	StoreEncryptedFile = "encrypted-file"
+	StoreFile          = "file"
+
+	keyringService = "go-git-it"
+)
+
+var (
+	CredentialStores = []string{StoreKeyring, StoreEncryptedFile, StoreFile}
+
+	// encryptedStores are shared so the passphrase is asked for at most
+	// once per run.
+	encryptedStores = map[string]*encryptedFileStore{}
+
+	// PassphraseFunc supplies the passphrase for the encrypted-file store.
+	// confirm is true when a new passphrase is being chosen. The cmd layer
+	// replaces it with a terminal prompt; by default only $GGI_PASSPHRASE is
+	// consulted.
+	PassphraseFunc = func(confirm bool) (string, error) {
+		if p := os.Getenv("GGI_PASSPHRASE"); p != "" {
+			return p, nil
+		}
+		return "", errors.New("a passphrase is needed to unlock the encrypted token, set $GGI_PASSPHRASE")
+	}
+)
+
+// Credential is what ggi keeps in a credential store. Tokens that expire, such
+// as GitHub App user tokens, are stored as JSON together with their refresh
+// token; tokens that do not are stored as is, which is also how every token
+// was stored before refresh support.
+type Credential struct {
+	AccessToken           string     `json:"access_token"`
+	ExpiresAt             *time.Time `json:"expires_at,omitempty"`
+	RefreshToken          string     `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at,omitempty"`
+}
+
+func ParseCredential(s string) *Credential {
+	var cred Credential
+	if strings.HasPrefix(s, "{") && json.Unmarshal([]byte(s), &cred) == nil && cred.AccessToken != "" {
+		return &cred
+	}
+	return &Credential{AccessToken: s}
+}
+
+func (c *Credential) String() string {
+	if c.RefreshToken == "" {
+		return c.AccessToken
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return c.AccessToken
+	}
+	return string(data)
+}
+
+// Expired reports whether the access token expires within margin.
+func (c *Credential) Expired(margin time.Duration) bool {
+	return c.ExpiresAt != nil && time.Until(*c.ExpiresAt) < margin
+}
+
+// CredentialStore is somewhere ggi can keep the user's GitHub token.
+type CredentialStore interface {
+	Name() string
+	// Get returns ErrNotAuthenticated when no token has been stored.
+	Get() (string, error)
+	Set(token string) error
+	// Delete removes the stored token. Deleting a missing token is not an
+	// error.
+	Delete() error
+}
+
+func sharedEncryptedStore(path string) *encryptedFileStore {
+	if s, ok := encryptedStores[path]; ok {
+		return s
+	}
+	s := &encryptedFileStore{path: path}
+	encryptedStores[path] = s
+	return s
+}
+
+// keyringStore keeps the token in the OS keyring: the Secret Service
+// (GNOME Keyring, KWallet via libsecret) on Linux, the Keychain on macOS and
+// the Credential Manager on Windows.
+type keyringStore struct {
+	account string
+}
+
+func (s *keyringStore) Name() string { return StoreKeyring }
+
+func (s *keyringStore) Get() (string, error) {
+	token, err := keyring.Get(keyringService, s.account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotAuthenticated
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read token from keyring with %w", err)
+	}
+	return token, nil
+}
+
+func (s *keyringStore) Set(token string) error {
+	if err := keyring.Set(keyringService, s.account, token); err != nil {
+		return fmt.Errorf("failed to save token to keyring with %w (use --store encrypted-file if no keyring is available)", err)
+	}
+	return nil
+}
+
+func (s *keyringStore) Delete() error {
+	err := keyring.Delete(keyringService, s.account)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete token from keyring with %w", err)
+	}
+	return nil
+}
+
+// encryptedFileStore keeps the token in a file encrypted with AES-256-GCM
+// under a key derived from a passphrase with scrypt.
+type encryptedFileStore struct {
+	path       string
+	token      string
+	passphrase string
+}
+
+type encryptedToken struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (s *encryptedFileStore) Name() string { return StoreEncryptedFile }
+
+func (s *encryptedFileStore) Get() (string, error) {
+	if s.token != "" {
+		return s.token, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", ErrNotAuthenticated
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read encrypted token with %w", err)
+	}
+
+	var enc encryptedToken
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return "", fmt.Errorf("failed to parse encrypted token with %w", err)
+	}
+
+	passphrase := s.passphrase
+	if passphrase == "" {
+		if passphrase, err = PassphraseFunc(false); err != nil {
+			return "", err
+		}
+	}
+	gcm, err := newGCM(passphrase, enc.Salt)
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, enc.Nonce, enc.Ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt token, wrong passphrase?")
+	}
+
+	s.token, s.passphrase = string(plain), passphrase
+	return s.token, nil
+}
+
+// Set encrypts token with the passphrase the store was unlocked with, so
+// that a refreshed token can be saved without asking again, or with a newly
+// chosen one.
+func (s *encryptedFileStore) Set(token string) error {
+	passphrase := s.passphrase
+	if passphrase == "" {
+		var err error
+		if passphrase, err = PassphraseFunc(true); err != nil {
+			return err
+		}
+	}
+
+	enc := encryptedToken{Salt: make([]byte, 16)}
+	if _, err := io.ReadFull(rand.Reader, enc.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, enc.Salt)
+	if err != nil {
+		return err
+	}
+	enc.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, enc.Nonce); err != nil {
+		return err
+	}
+	enc.Ciphertext = gcm.Seal(nil, enc.Nonce, []byte(token), nil)
+
+	data, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+	if err := writePrivateFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to save encrypted token with %w", err)
+	}
+	s.token, s.passphrase = token, passphrase
+	return nil
+}
+
+func (s *encryptedFileStore) Delete() error {
+	s.token, s.passphrase = "", ""
+	return removeIfExists(s.path)
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// plainFileStore is the original .token file in the profile's directory. It
+// is only used when explicitly requested, or for profiles created before
+// credential stores existed whose token could not be moved to the keyring.
+type plainFileStore struct {
+	path string
+}
+
+func (s *plainFileStore) Name() string { return StoreFile }
+
+func (s *plainFileStore) Get() (string, error) {
+	token, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", ErrNotAuthenticated
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read token with %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func (s *plainFileStore) Set(token string) error {
+	if err := writePrivateFile(s.path, []byte(token)); err != nil {
+		return fmt.Errorf("failed to save token with %w", err)
+	}
+	return nil
+}
+
+func (s *plainFileStore) Delete() error {
+	return removeIfExists(s.path)
+}
+
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
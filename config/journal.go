@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalLimit is how many operations the journal keeps for `undo`.
+const journalLimit = 50
+
+// Kinds of operations recorded in the journal.
+const (
+	OpClose      = "close"
+	OpReopen     = "reopen"
+	OpStatus     = "status"
+	OpLabel      = "label"
+	OpDeleteTask = "del-task"
+	OpDeadline   = "deadline"
+	OpPriority   = "priority"
+	OpAdd        = "add"
+)
+
+// Operation is a change ggi made to a to-do repo that `undo` can reverse.
+type Operation struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	Repo RepoRef   `json:"repo"`
+	// Issue, State and Labels are the issue that was changed along with its
+	// state and labels before the change.
+	Issue  int      `json:"issue,omitempty"`
+	State  string   `json:"state,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	// Value is the deadline (YYYY-MM-DD) or priority the issue had before the
+	// change, or "" if it had none.
+	Value string `json:"value,omitempty"`
+	// File and Commit are the deleted task file and the commit that deleted
+	// it, or for an added task its task file and the commit that added it.
+	File   string `json:"file,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+func journalPath() string {
+	return filepath.Join(ActiveProfileDir(), "journal.json")
+}
+
+func readJournal() ([]Operation, error) {
+	data, err := os.ReadFile(journalPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+func writeJournal(ops []Operation) error {
+	if len(ops) == 0 {
+		return removeIfExists(journalPath())
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(journalPath(), data)
+}
+
+// RecordOperation appends op to the active profile's journal, dropping the
+// oldest operations beyond journalLimit.
+func RecordOperation(op Operation) error {
+	ops, err := readJournal()
+	if err != nil {
+		return err
+	}
+	if op.Time.IsZero() {
+		op.Time = time.Now()
+	}
+	ops = append(ops, op)
+	if len(ops) > journalLimit {
+		ops = ops[len(ops)-journalLimit:]
+	}
+	return writeJournal(ops)
+}
+
+// LastOperation returns the most recent operation in the journal, or nil if
+// it is empty.
+func LastOperation() (*Operation, error) {
+	ops, err := readJournal()
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+	return &ops[len(ops)-1], nil
+}
+
+// PopOperation removes the most recent operation from the journal.
+func PopOperation() error {
+	ops, err := readJournal()
+	if err != nil || len(ops) == 0 {
+		return err
+	}
+	return writeJournal(ops[:len(ops)-1])
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile kept directly in the config dir, where the
+// single profile lived before named profiles existed. Other profiles live in
+// profiles/<name> with the same layout.
+const DefaultProfile = "default"
+
+var (
+	configDir         = filepath.Join(os.Getenv("HOME"), ".go-git-it")
+	activeProfilePath = filepath.Join(configDir, "active-profile")
+
+	activeProfile string
+
+	profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// ActiveProfile returns the profile commands work with: the one selected with
+// SetActiveProfile (the --profile flag), else $GGI_PROFILE, else the one
+// chosen with `ggi profile use`, else the default profile.
+func ActiveProfile() string {
+	if activeProfile != "" {
+		return activeProfile
+	}
+	if name := os.Getenv("GGI_PROFILE"); name != "" {
+		return name
+	}
+	if data, err := os.ReadFile(activeProfilePath); err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" {
+			return name
+		}
+	}
+	return DefaultProfile
+}
+
+// SetActiveProfile selects the profile for the rest of this run.
+func SetActiveProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist, create it with 'profile add'", name)
+	}
+	activeProfile = name
+	return nil
+}
+
+// UseProfile makes name the active profile for future runs.
+func UseProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist, create it with 'profile add'", name)
+	}
+	if name == DefaultProfile {
+		return removeIfExists(activeProfilePath)
+	}
+	return writePrivateFile(activeProfilePath, []byte(name+"\n"))
+}
+
+func ProfileDir(name string) string {
+	if name == DefaultProfile {
+		return configDir
+	}
+	return filepath.Join(configDir, "profiles", name)
+}
+
+// ActiveProfileDir is where the active profile keeps its files.
+func ActiveProfileDir() string {
+	return ProfileDir(ActiveProfile())
+}
+
+// ReposDir is where the active profile's to-do repos are cloned.
+func ReposDir() string {
+	return filepath.Join(ActiveProfileDir(), "repos")
+}
+
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	if !profileName.MatchString(name) {
+		return false
+	}
+	info, err := os.Stat(ProfileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// ListProfiles returns the names of all profiles, starting with the default
+// one.
+func ListProfiles() ([]string, error) {
+	names := []string{DefaultProfile}
+	entries, err := os.ReadDir(filepath.Join(configDir, "profiles"))
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var named []string
+	for _, e := range entries {
+		if e.IsDir() && profileName.MatchString(e.Name()) {
+			named = append(named, e.Name())
+		}
+	}
+	sort.Strings(named)
+	return append(names, named...), nil
+}
+
+// AddProfile creates a new, empty profile for host.
+func AddProfile(name, host string) (*UserProfile, error) {
+	if !profileName.MatchString(name) {
+		return nil, fmt.Errorf("invalid profile name %q, use letters, digits, '-', '_' and '.'", name)
+	}
+	if ProfileExists(name) {
+		return nil, fmt.Errorf("profile %q already exists", name)
+	}
+
+	profile := &UserProfile{name: name}
+	profile.SetHost(host)
+	if err := profile.Save(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// RemoveProfile deletes a named profile, including its stored token and its
+// cloned repos. The default profile cannot be removed.
+func RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return errors.New("the default profile cannot be removed")
+	}
+	profile, err := LoadProfile(name)
+	if err != nil {
+		return err
+	}
+
+	if store, err := profile.TokenStore(); err == nil {
+		if err := store.Delete(); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(ProfileDir(name)); err != nil {
+		return err
+	}
+
+	if data, err := os.ReadFile(activeProfilePath); err == nil && strings.TrimSpace(string(data)) == name {
+		return removeIfExists(activeProfilePath)
+	}
+	return nil
+}
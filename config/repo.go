@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RepoRef identifies a to-do repo by its owner (a user or an organization)
+// and name. It is stored as "owner/name"; profiles written before repos could
+// belong to an organization store only the name, and LoadProfile fills in the
+// profile's username as the owner.
+type RepoRef struct {
+	Owner string
+	Name  string
+}
+
+// ParseRepoRef parses "owner/name", or just "name" for a repo owned by
+// defaultOwner.
+func ParseRepoRef(s, defaultOwner string) (RepoRef, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".git")
+	owner, name, found := strings.Cut(s, "/")
+	if !found {
+		owner, name = defaultOwner, s
+	}
+	if name == "" || strings.Contains(name, "/") || (found && owner == "") {
+		return RepoRef{}, fmt.Errorf("invalid repo %q, expected owner/name", s)
+	}
+	return RepoRef{Owner: owner, Name: name}, nil
+}
+
+func (r RepoRef) String() string {
+	if r.Owner == "" {
+		return r.Name
+	}
+	return r.Owner + "/" + r.Name
+}
+
+func (r RepoRef) IsZero() bool {
+	return r.Name == ""
+}
+
+// Equal compares repos the way GitHub does, ignoring case.
+func (r RepoRef) Equal(other RepoRef) bool {
+	return strings.EqualFold(r.Owner, other.Owner) && strings.EqualFold(r.Name, other.Name)
+}
+
+func (r RepoRef) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *RepoRef) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = RepoRef{}
+		return nil
+	}
+	ref, err := ParseRepoRef(string(text), "")
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+// ownerDirPrefix marks the dirs holding the clones of other owners' repos.
+// GitHub names cannot start with "@", so an owner dir never collides with a
+// clone of one of the user's own repos.
+const ownerDirPrefix = "@"
+
+// LocalRepoPath is where repo is cloned. Repos owned by username keep the
+// original repos/<name> layout; repos of other owners go in
+// repos/@<owner>/<name> so that equally named repos and owners do not
+// collide.
+func LocalRepoPath(repo RepoRef, username string) string {
+	if repo.Owner == "" || strings.EqualFold(repo.Owner, username) {
+		return filepath.Join(ReposDir(), repo.Name)
+	}
+	return filepath.Join(ReposDir(), ownerDirPrefix+repo.Owner, repo.Name)
+}
+
+// LocalClones lists the repos cloned in the active profile's repos dir, in
+// either layout described at LocalRepoPath.
+func LocalClones(username string) ([]RepoRef, error) {
+	entries, err := os.ReadDir(ReposDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var clones []RepoRef
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(ReposDir(), e.Name())
+		owner, found := strings.CutPrefix(e.Name(), ownerDirPrefix)
+		if !found {
+			if isGitDir(dir) {
+				clones = append(clones, RepoRef{Owner: username, Name: e.Name()})
+			}
+			continue
+		}
+		subs, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subs {
+			if sub.IsDir() && isGitDir(filepath.Join(dir, sub.Name())) {
+				clones = append(clones, RepoRef{Owner: owner, Name: sub.Name()})
+			}
+		}
+	}
+	return clones, nil
+}
+
+func isGitDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
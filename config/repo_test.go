@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useTempConfigDir points the config dir at a temporary dir for the rest of
+// the test.
+func useTempConfigDir(t *testing.T) string {
+	dir := t.TempDir()
+	oldConfigDir, oldActivePath := configDir, activeProfilePath
+	configDir, activeProfilePath = dir, filepath.Join(dir, "active-profile")
+	t.Cleanup(func() {
+		configDir, activeProfilePath = oldConfigDir, oldActivePath
+	})
+	t.Setenv("GGI_PROFILE", "")
+	return dir
+}
+
+func TestParseRepoRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RepoRef
+	}{
+		{"todo", RepoRef{Owner: "me", Name: "todo"}},
+		{"acme/todo", RepoRef{Owner: "acme", Name: "todo"}},
+		{" acme/todo.git ", RepoRef{Owner: "acme", Name: "todo"}},
+		{"todo.git", RepoRef{Owner: "me", Name: "todo"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRepoRef(tt.in, "me")
+		if err != nil {
+			t.Errorf("ParseRepoRef(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRepoRef(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "acme/", "/todo", "acme/todo/extra"} {
+		if _, err := ParseRepoRef(in, "me"); err == nil {
+			t.Errorf("ParseRepoRef(%q) should fail", in)
+		}
+	}
+}
+
+func TestLocalRepoPath(t *testing.T) {
+	dir := useTempConfigDir(t)
+	repos := filepath.Join(dir, "repos")
+
+	tests := []struct {
+		repo RepoRef
+		want string
+	}{
+		{RepoRef{Owner: "me", Name: "todo"}, filepath.Join(repos, "todo")},
+		{RepoRef{Owner: "Me", Name: "todo"}, filepath.Join(repos, "todo")},
+		{RepoRef{Name: "todo"}, filepath.Join(repos, "todo")},
+		{RepoRef{Owner: "acme", Name: "todo"}, filepath.Join(repos, "@acme", "todo")},
+		// The user's own repo named like an org must not end up in the
+		// org's dir.
+		{RepoRef{Owner: "me", Name: "acme"}, filepath.Join(repos, "acme")},
+	}
+	for _, tt := range tests {
+		if got := LocalRepoPath(tt.repo, "me"); got != tt.want {
+			t.Errorf("LocalRepoPath(%v) = %s, want %s", tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestLocalClones(t *testing.T) {
+	useTempConfigDir(t)
+	want := []RepoRef{
+		{Owner: "acme", Name: "todo"},
+		{Owner: "me", Name: "acme"},
+		{Owner: "me", Name: "todo"},
+	}
+	for _, repo := range want {
+		if err := os.MkdirAll(filepath.Join(LocalRepoPath(repo, "me"), ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Neither a clone nor an owner dir.
+	if err := os.MkdirAll(filepath.Join(ReposDir(), "scratch", "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LocalClones("me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LocalClones = %v, want %v", got, want)
+	}
+}
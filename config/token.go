@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// tokenEnvVars are checked in order before any configured or stored token.
+// GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN only apply to GitHub
+// Enterprise Server hosts, as with the gh CLI.
+var (
+	tokenEnvVars           = []string{"GGI_TOKEN", "GH_TOKEN", "GITHUB_TOKEN"}
+	enterpriseTokenEnvVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+)
+
+// ResolveToken finds the token to authenticate with, in order of precedence:
+//
+//  1. $GGI_TOKEN, $GH_TOKEN, $GITHUB_TOKEN (and, for GitHub Enterprise
+//     Server hosts, $GH_ENTERPRISE_TOKEN and $GITHUB_ENTERPRISE_TOKEN)
+//  2. the output of the profile's token helper command
+//  3. the token saved by `ggi login` in the profile's credential store
+//
+// It also returns a short description of where the token came from.
+func ResolveToken() (string, string, error) {
+	cred, source, err := ResolveCredential()
+	if err != nil {
+		return "", "", err
+	}
+	return cred.AccessToken, source, nil
+}
+
+// ResolveCredential is ResolveToken but returns the whole credential, which
+// for a stored token may include a refresh token.
+func ResolveCredential() (*Credential, string, error) {
+	profile, err := LoadUserProfile()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load user profile with %w", err)
+	}
+
+	vars := tokenEnvVars
+	if profile.GetHost() != DefaultHost {
+		vars = append(vars[:len(vars):len(vars)], enterpriseTokenEnvVars...)
+	}
+	for _, name := range vars {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return &Credential{AccessToken: token}, "$" + name, nil
+		}
+	}
+
+	if helper := profile.GetTokenHelper(); helper != "" {
+		token, err := RunTokenHelper(helper)
+		if err != nil {
+			return nil, "", err
+		}
+		return &Credential{AccessToken: token}, "token helper", nil
+	}
+
+	store, err := profile.TokenStore()
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := store.Get()
+	if err != nil {
+		return nil, "", err
+	}
+	return ParseCredential(token), store.Name() + " store", nil
+}
+
+// RunTokenHelper runs command with the shell and returns the token it prints
+// on stdout.
+func RunTokenHelper(command string) (string, error) {
+	var stderr bytes.Buffer
+	helper := exec.Command("sh", "-c", command)
+	helper.Stderr = &stderr
+	out, err := helper.Output()
+	if err != nil {
+		return "", fmt.Errorf("token helper %q failed with %v: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("token helper %q did not print a token", command)
+	}
+	return token, nil
+}
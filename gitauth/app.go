@@ -0,0 +1,77 @@
+package gitauth
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"time"
+)
+
+// GetJWT signs a JWT for the GitHub App appID (its numeric ID or client ID)
+// with the PEM private key at keyPath. GitHub accepts app JWTs for at most ten
+// minutes, and iat is backdated to allow for clock drift.
+func GetJWT(appID, keyPath string) (string, error) {
+	pemKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read app private key with %v", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse app private key with %v", err)
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iat": jwt.NewNumericDate(now.Add(-time.Minute)),
+		"exp": jwt.NewNumericDate(now.Add(9 * time.Minute)),
+		"iss": appID,
+	})
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app JWT with %v", err)
+	}
+	return tokenString, nil
+}
+
+// LoginApp switches the profile to authenticate as the given installation of
+// a GitHub App. It checks that an installation token can be obtained and
+// returns the account (usually an organization) the app is installed on,
+// which becomes the owner of the profile's to-do repos.
+func LoginApp(appID string, installationID int64, keyPath string) (string, error) {
+	profile, err := config.LoadUserProfile()
+	if err != nil {
+		return "", fmt.Errorf("failed to load user profile with %v", err)
+	}
+
+	gitops.ClearInstallationTokens()
+	source := gitops.NewInstallationTokenSource(profile.GetHost(), appID, installationID, keyPath)
+	installation, err := source.Installation()
+	if err != nil {
+		return "", fmt.Errorf("failed to look up installation %d with %w", installationID, err)
+	}
+	if installation.Account == nil {
+		return "", fmt.Errorf("installation %d has no account", installationID)
+	}
+	if _, err := source.Token(); err != nil {
+		return "", err
+	}
+
+	profile.SetApp(appID, installationID, keyPath)
+	profile.SetUsername(installation.Account.Login)
+	if err := profile.Save(); err != nil {
+		return "", fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return installation.Account.Login, nil
+}
+
+func logoutApp(profile *config.UserProfile) error {
+	gitops.ClearInstallationTokens()
+	profile.SetApp("", 0, "")
+	profile.SetUsername("")
+	if err := profile.Save(); err != nil {
+		return fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return nil
+}
@@ -3,13 +3,14 @@ package gitauth
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/golang-jwt/jwt/v5"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"strings"
 	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
 	"time"
 )
 
@@ -18,32 +19,32 @@ const (
 	CLIEN_SECRET = "secret"
 )
 
+var (
+	ErrDeviceCodeExpired = errors.New("the device code has expired, please run `login` again")
+	ErrAccessDenied      = errors.New("login cancelled by user")
+	ErrRevokeUnavailable = errors.New("no client secret is configured, set $GGI_CLIENT_SECRET to revoke tokens")
+)
+
+func init() {
+	gitops.AppJWT = GetJWT
+	gitops.RefreshUserToken = refreshUserToken
+}
+
 func parseResponse(response *http.Response) (map[string]interface{}, error) {
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
+	if err := gitops.CheckResponse(response); err != nil {
 		return nil, err
 	}
 
-	if response.StatusCode == http.StatusOK || response.StatusCode == http.StatusCreated {
-		var data map[string]interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
-			return nil, err
-		}
-		return data, nil
-	} else if response.StatusCode == http.StatusUnauthorized {
-		fmt.Println("You are not authorized. Run the `login` command.")
-		os.Exit(1)
-	} else {
-		fmt.Println(response)
-		fmt.Println(string(body))
-		os.Exit(1)
+	var data map[string]interface{}
+	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode response with %v", err)
 	}
-	return nil, nil
+	return data, nil
 }
 
-func requestDeviceCode() (map[string]interface{}, error) {
+func requestDeviceCode(host, clientID string) (map[string]interface{}, error) {
 	client := &http.Client{}
-	req, err := http.NewRequest("POST", "https://github.com/login/device/code", bytes.NewBufferString("client_id="+CLIENT_ID))
+	req, err := http.NewRequest("POST", config.WebURL(host)+"/login/device/code", bytes.NewBufferString("client_id="+clientID))
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +57,10 @@ func requestDeviceCode() (map[string]interface{}, error) {
 	return parseResponse(resp)
 }
 
-func requestToken(deviceCode string) (map[string]interface{}, error) {
+func requestToken(host, clientID, deviceCode string) (map[string]interface{}, error) {
 	client := &http.Client{}
-	data := "client_id=" + CLIENT_ID + "&device_code=" + deviceCode + "&grant_type=urn:ietf:params:oauth:grant-type:device_code"
-	req, err := http.NewRequest("POST", "https://github.com/login/oauth/access_token", bytes.NewBufferString(data))
+	data := "client_id=" + clientID + "&device_code=" + deviceCode + "&grant_type=urn:ietf:params:oauth:grant-type:device_code"
+	req, err := http.NewRequest("POST", config.WebURL(host)+"/login/oauth/access_token", bytes.NewBufferString(data))
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +73,11 @@ func requestToken(deviceCode string) (map[string]interface{}, error) {
 	return parseResponse(resp)
 }
 
-func pollForToken(deviceCode string, interval int) {
+func pollForToken(host, clientID, deviceCode string, interval int) (*config.Credential, error) {
 	for {
-		response, err := requestToken(deviceCode)
+		response, err := requestToken(host, clientID, deviceCode)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return nil, err
 		}
 		errorType, ok := response["error"].(string)
 		if ok {
@@ -86,120 +86,343 @@ func pollForToken(deviceCode string, interval int) {
 				time.Sleep(time.Duration(interval) * time.Second)
 				continue
 			case "slow_down":
-				time.Sleep(time.Duration(interval+5) * time.Second)
+				interval += 5
+				time.Sleep(time.Duration(interval) * time.Second)
 				continue
 			case "expired_token":
-				fmt.Println("The device code has expired. Please run `login` again.")
-				os.Exit(1)
+				return nil, ErrDeviceCodeExpired
 			case "access_denied":
-				fmt.Println("Login cancelled by user.")
-				os.Exit(1)
+				return nil, ErrAccessDenied
 			default:
-				fmt.Println(response)
-				os.Exit(1)
+				return nil, fmt.Errorf("device flow failed with %s: %v", errorType, response["error_description"])
 			}
 		}
 
-		accessToken, ok := response["access_token"].(string)
-		if ok {
-			tokenPath := filepath.Join(os.Getenv("HOME"), ".go-git-it", ".token")
-			ioutil.WriteFile(tokenPath, []byte(accessToken), 0600)
-			break
+		if cred := credentialFromResponse(response); cred != nil {
+			return cred, nil
 		}
 	}
 }
 
-func Login() {
-	deviceCodeResponse, err := requestDeviceCode()
+// credentialFromResponse reads a token response from the OAuth endpoint. The
+// expiry and refresh token are only present for GitHub Apps with expiring user
+// tokens enabled.
+func credentialFromResponse(response map[string]interface{}) *config.Credential {
+	accessToken, ok := response["access_token"].(string)
+	if !ok {
+		return nil
+	}
+	cred := &config.Credential{AccessToken: accessToken}
+	cred.RefreshToken, _ = response["refresh_token"].(string)
+
+	expiry := func(key string) *time.Time {
+		seconds, ok := response[key].(float64)
+		if !ok || seconds <= 0 {
+			return nil
+		}
+		t := time.Now().Add(time.Duration(seconds) * time.Second)
+		return &t
+	}
+	cred.ExpiresAt = expiry("expires_in")
+	cred.RefreshTokenExpiresAt = expiry("refresh_token_expires_in")
+	return cred
+}
+
+// RefreshToken exchanges refreshToken for a new access token (and a new
+// refresh token). It returns config.ErrSessionExpired if the refresh token is
+// no longer valid.
+func RefreshToken(host, clientID, refreshToken string) (*config.Credential, error) {
+	form := url.Values{
+		"client_id":     {clientID},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	if secret := clientSecret(); secret != "" {
+		form.Set("client_secret", secret)
+	}
+
+	req, err := http.NewRequest("POST", config.WebURL(host)+"/login/oauth/access_token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to refresh token with %w", err)
+	}
+	defer resp.Body.Close()
+	response, err := parseResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token with %w", err)
+	}
+
+	if errorType, ok := response["error"].(string); ok {
+		if errorType == "bad_refresh_token" {
+			return nil, config.ErrSessionExpired
+		}
+		return nil, fmt.Errorf("failed to refresh token with %s: %v", errorType, response["error_description"])
+	}
+	cred := credentialFromResponse(response)
+	if cred == nil {
+		return nil, errors.New("failed to refresh token: no access token in the response")
+	}
+	return cred, nil
+}
+
+func refreshUserToken(host, refreshToken string) (*config.Credential, error) {
+	profile, err := config.LoadUserProfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user profile with %v", err)
+	}
+	return RefreshToken(host, profile.GetClientID(CLIENT_ID), refreshToken)
+}
+
+// Login runs the device flow and saves the token in the given credential
+// store (one of config.CredentialStores).
+func Login(storeKind string) error {
+	profile, err := config.LoadUserProfile()
+	if err != nil {
+		return fmt.Errorf("failed to load user profile with %v", err)
+	}
+	host := profile.GetHost()
+	clientID := profile.GetClientID(CLIENT_ID)
+
+	store, err := profile.NewCredentialStore(storeKind)
+	if err != nil {
+		return err
+	}
+
+	deviceCodeResponse, err := requestDeviceCode(host, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to request device code with %w", err)
 	}
 	verificationURI, ok1 := deviceCodeResponse["verification_uri"].(string)
 	userCode, ok2 := deviceCodeResponse["user_code"].(string)
 	deviceCode, ok3 := deviceCodeResponse["device_code"].(string)
 	interval, ok4 := deviceCodeResponse["interval"].(float64)
 	if !ok1 || !ok2 || !ok3 || !ok4 {
-		fmt.Println("Error parsing response.")
-		os.Exit(1)
+		return fmt.Errorf("error parsing device code response")
 	}
 
 	fmt.Printf("Please visit: %s\nand enter code: %s\n", verificationURI, userCode)
 
-	pollForToken(deviceCode, int(interval))
+	cred, err := pollForToken(host, clientID, deviceCode, int(interval))
+	if err != nil {
+		return err
+	}
+	if err := saveToken(profile, store, cred); err != nil {
+		return err
+	}
 
 	fmt.Printf("Successfully authenticated! Configurating username...\n")
 
+	username, err := WhoamiToken(host, cred.AccessToken)
+	if err != nil {
+		return err
+	}
+	profile.SetUsername(username)
+	if err := profile.Save(); err != nil {
+		return fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return nil
+}
+
+// LoginWithToken checks that token (e.g. a personal access token) is accepted
+// by the profile's host and saves it in the given credential store.
+func LoginWithToken(storeKind, token string) (string, error) {
 	profile, err := config.LoadUserProfile()
 	if err != nil {
-		fmt.Printf("failed to load user profile with %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to load user profile with %v", err)
 	}
-	username := Whoami()
+	store, err := profile.NewCredentialStore(storeKind)
+	if err != nil {
+		return "", err
+	}
+
+	username, err := WhoamiToken(profile.GetHost(), token)
+	if err != nil {
+		return "", fmt.Errorf("failed to validate token with %w", err)
+	}
+	if err := saveToken(profile, store, &config.Credential{AccessToken: token}); err != nil {
+		return "", err
+	}
+
 	profile.SetUsername(username)
 	if err := profile.Save(); err != nil {
-		fmt.Printf("failed to save user profile with %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to save user profile with %v", err)
 	}
+	return username, nil
 }
 
-func Whoami() string {
-	tokenPath := filepath.Join(os.Getenv("HOME"), ".go-git-it", ".token")
-	token, err := ioutil.ReadFile(tokenPath)
+// SetTokenHelper makes the profile get its token from a helper command such
+// as "gh auth token", after checking that the token it prints is accepted.
+// An empty command removes the helper.
+func SetTokenHelper(command string) (string, error) {
+	profile, err := config.LoadUserProfile()
 	if err != nil {
-		fmt.Println("You are not authorized. Run the `login` command.")
-		os.Exit(1)
+		return "", fmt.Errorf("failed to load user profile with %v", err)
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+	username := profile.GetUsername()
+	if command != "" {
+		token, err := config.RunTokenHelper(command)
+		if err != nil {
+			return "", err
+		}
+		username, err = WhoamiToken(profile.GetHost(), token)
+		if err != nil {
+			return "", fmt.Errorf("failed to validate token with %w", err)
+		}
+		profile.SetUsername(username)
+	}
+
+	profile.SetTokenHelper(command)
+	if err := profile.Save(); err != nil {
+		return "", fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return username, nil
+}
+
+// saveToken stores cred and switches the profile over to store, removing any
+// token left behind in the store it used before.
+func saveToken(profile *config.UserProfile, store config.CredentialStore, cred *config.Credential) error {
+	if err := store.Set(cred.String()); err != nil {
+		return err
+	}
+
+	if previous := profile.GetCredentialStore(); previous != store.Name() {
+		if old, err := profile.TokenStore(); err == nil {
+			if err := old.Delete(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to remove the token from the %s store: %v\n", previous, err)
+			}
+		}
+	}
+
+	profile.SetCredentialStore(store.Name())
+	// Signing in as a user replaces any GitHub App login.
+	if profile.UsesApp() {
+		gitops.ClearInstallationTokens()
+		profile.SetApp("", 0, "")
+	}
+	if err := profile.Save(); err != nil {
+		return fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return nil
+}
+
+// clientSecret returns the OAuth client secret of the ggi app, which is only
+// needed to revoke tokens. Builds without a real secret can provide one via
+// $GGI_CLIENT_SECRET.
+func clientSecret() string {
+	if s := os.Getenv("GGI_CLIENT_SECRET"); s != "" {
+		return s
+	}
+	if CLIEN_SECRET == "secret" {
+		return ""
+	}
+	return CLIEN_SECRET
+}
+
+// RevokeToken deletes the app's grant for token, which revokes it along with
+// every other token the app holds for the user.
+func RevokeToken(host, clientID, token string) error {
+	secret := clientSecret()
+	if secret == "" {
+		return ErrRevokeUnavailable
+	}
+
+	body, err := json.Marshal(map[string]string{"access_token": token})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("DELETE", config.APIBaseURL(host)+"/applications/"+clientID+"/grant", bytes.NewReader(body))
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+string(token))
+	req.SetBasicAuth(clientID, secret)
 	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
 	defer resp.Body.Close()
+	// A 404 means the grant is already gone.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return gitops.CheckResponse(resp)
+}
 
-	response, err := parseResponse(resp)
+// Logout revokes the stored token where possible, removes it from the
+// profile's credential store and forgets the username. It reports whether the
+// token was revoked; a failed revocation only produces a warning since the
+// token is gone locally either way.
+func Logout() (bool, error) {
+	profile, err := config.LoadUserProfile()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return false, fmt.Errorf("failed to load user profile with %v", err)
 	}
-
-	login, ok := response["login"].(string)
-	if !ok {
-		fmt.Println("Error parsing response.")
-		os.Exit(1)
+	if profile.UsesApp() {
+		return false, logoutApp(profile)
+	}
+	store, err := profile.TokenStore()
+	if err != nil {
+		return false, err
 	}
-	return login
-}
 
-func GetJWT() string {
+	revoked := false
+	token, err := store.Get()
+	if err == nil {
+		token = config.ParseCredential(token).AccessToken
+		err = RevokeToken(profile.GetHost(), profile.GetClientID(CLIENT_ID), token)
+		revoked = err == nil
+	}
+	if err != nil && !errors.Is(err, config.ErrNotAuthenticated) {
+		fmt.Fprintf(os.Stderr, "Warning: the token could not be revoked and is only removed locally: %v\n", err)
+	}
 
-	pemFilePath := ".env"
-	now := time.Now()
+	if err := store.Delete(); err != nil {
+		return false, err
+	}
+	profile.SetUsername("")
+	if err := profile.Save(); err != nil {
+		return revoked, fmt.Errorf("failed to save user profile with %v", err)
+	}
+	return revoked, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
-		"iat": jwt.NewNumericDate(now.Add(-time.Minute)),
-		"exp": jwt.NewNumericDate(now.Add(5 * time.Minute)),
-		"iss": CLIENT_ID,
-	})
+func Whoami() (string, error) {
+	client, err := gitops.NewClientFromProfile()
+	if err != nil {
+		return "", err
+	}
+	// Installation tokens cannot read /user; report the app's bot account.
+	if source, ok := client.TokenSource.(*gitops.InstallationTokenSource); ok {
+		app, err := source.App()
+		if err != nil {
+			return "", err
+		}
+		return app.Slug + "[bot]", nil
+	}
 
-	pemKey, _ := ioutil.ReadFile(pemFilePath)
+	user, err := client.CurrentUser()
+	if err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
 
-	privateKey, _ := jwt.ParseRSAPrivateKeyFromPEM(pemKey)
+// WhoamiToken returns the login of the user token belongs to on host.
+func WhoamiToken(host, token string) (string, error) {
+	client := gitops.NewClient(token, "")
+	client.SetHost(host)
 
-	tokenString, err := token.SignedString(privateKey)
+	user, err := client.CurrentUser()
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-
-	return tokenString
+	return user.Login, nil
 }
@@ -0,0 +1,152 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"teriyake/go-git-it/config"
+	"time"
+)
+
+// renewBefore is how long before expiry an installation token is replaced,
+// so that a token never runs out in the middle of a command.
+const renewBefore = 5 * time.Minute
+
+// installationTokens caches installation tokens in memory for the rest of
+// the run, keyed by installationTokenKey. They are never written to disk.
+var (
+	installationTokensMu sync.Mutex
+	installationTokens   = map[string]*installationToken{}
+)
+
+// AppJWT signs a GitHub App JWT for appID with the private key at keyPath.
+// It is provided by gitauth.
+var AppJWT func(appID, keyPath string) (string, error)
+
+// Installation is the account a GitHub App is installed on.
+type Installation struct {
+	ID      int64 `json:"id"`
+	Account *User `json:"account"`
+}
+
+// App is the GitHub App itself, as seen with its JWT.
+type App struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// InstallationTokenSource authenticates as a GitHub App installation. It
+// exchanges the app's JWT for an installation access token, caches the token
+// in memory and renews it shortly before it expires.
+type InstallationTokenSource struct {
+	BaseURL        string
+	AppID          string
+	InstallationID int64
+	PrivateKeyPath string
+
+	mu    sync.Mutex
+	token *installationToken
+}
+
+func NewInstallationTokenSource(host, appID string, installationID int64, keyPath string) *InstallationTokenSource {
+	return &InstallationTokenSource{
+		BaseURL:        config.APIBaseURL(host),
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKeyPath: keyPath,
+	}
+}
+
+func (s *InstallationTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil {
+		s.token = s.loadCached()
+	}
+	if s.token != nil && time.Until(s.token.ExpiresAt) > renewBefore {
+		return s.token.Token, nil
+	}
+
+	client, err := s.appClient()
+	if err != nil {
+		return "", err
+	}
+	var token installationToken
+	path := fmt.Sprintf("app/installations/%d/access_tokens", s.InstallationID)
+	if err := client.call("POST", path, nil, &token); err != nil {
+		return "", fmt.Errorf("failed to get installation token with %w", err)
+	}
+	s.token = &token
+
+	installationTokensMu.Lock()
+	installationTokens[s.cacheKey()] = &token
+	installationTokensMu.Unlock()
+	return token.Token, nil
+}
+
+// Installation looks up the installation the source authenticates as.
+func (s *InstallationTokenSource) Installation() (*Installation, error) {
+	client, err := s.appClient()
+	if err != nil {
+		return nil, err
+	}
+	var installation Installation
+	if err := client.call("GET", fmt.Sprintf("app/installations/%d", s.InstallationID), nil, &installation); err != nil {
+		return nil, err
+	}
+	return &installation, nil
+}
+
+// App looks up the GitHub App the source authenticates as.
+func (s *InstallationTokenSource) App() (*App, error) {
+	client, err := s.appClient()
+	if err != nil {
+		return nil, err
+	}
+	var app App
+	if err := client.call("GET", "app", nil, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// appClient returns a client authenticated as the app itself, which is only
+// allowed to call the /app endpoints.
+func (s *InstallationTokenSource) appClient() (*Client, error) {
+	if AppJWT == nil {
+		return nil, errors.New("GitHub App authentication is not available")
+	}
+	jwt, err := AppJWT(s.AppID, s.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient(jwt, "")
+	client.BaseURL = s.BaseURL
+	return client, nil
+}
+
+// cacheKey identifies the installation token of the source, so that tokens
+// of different apps, installations or hosts never get mixed up.
+func (s *InstallationTokenSource) cacheKey() string {
+	return fmt.Sprintf("%s|%s|%d", s.BaseURL, s.AppID, s.InstallationID)
+}
+
+func (s *InstallationTokenSource) loadCached() *installationToken {
+	installationTokensMu.Lock()
+	defer installationTokensMu.Unlock()
+	return installationTokens[s.cacheKey()]
+}
+
+// ClearInstallationTokens forgets all cached installation tokens.
+func ClearInstallationTokens() {
+	installationTokensMu.Lock()
+	defer installationTokensMu.Unlock()
+	installationTokens = map[string]*installationToken{}
+}
@@ -0,0 +1,32 @@
+package gitops
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInstallationTokenCache(t *testing.T) {
+	defer ClearInstallationTokens()
+
+	a := NewInstallationTokenSource("github.com", "1", 7, "key.pem")
+	installationTokens[a.cacheKey()] = &installationToken{Token: "a", ExpiresAt: time.Now().Add(time.Hour)}
+
+	if token, err := NewInstallationTokenSource("github.com", "1", 7, "key.pem").Token(); err != nil || token != "a" {
+		t.Errorf("Token = %q, %v, want the cached token", token, err)
+	}
+	others := []*InstallationTokenSource{
+		NewInstallationTokenSource("github.com", "2", 7, "key.pem"),
+		NewInstallationTokenSource("github.com", "1", 8, "key.pem"),
+		NewInstallationTokenSource("ghe.example.com", "1", 7, "key.pem"),
+	}
+	for _, s := range others {
+		if token := s.loadCached(); token != nil {
+			t.Errorf("source for app %s, installation %d at %s got the token of another", s.AppID, s.InstallationID, s.BaseURL)
+		}
+	}
+
+	ClearInstallationTokens()
+	if token := a.loadCached(); token != nil {
+		t.Errorf("loadCached after ClearInstallationTokens = %+v, want nil", token)
+	}
+}
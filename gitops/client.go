@@ -0,0 +1,207 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"teriyake/go-git-it/config"
+)
+
+const (
+	defaultUserAgent  = "go-git-it"
+	defaultAPIVersion = "2022-11-28"
+	mediaTypeJSON     = "application/vnd.github+json"
+)
+
+// Client talks to the GitHub REST API on behalf of a single user. The zero
+// value is not usable; construct one with NewClient or NewClientFromProfile.
+type Client struct {
+	BaseURL  string
+	Host     string
+	Token    string
+	Username string
+	// TokenSource, if set, is asked for the token of every request instead
+	// of using Token.
+	TokenSource TokenSource
+	UserAgent   string
+	HTTPClient  *http.Client
+}
+
+func NewClient(token, username string) *Client {
+	return &Client{
+		BaseURL:    baseUrl,
+		Host:       config.DefaultHost,
+		Token:      strings.TrimSpace(token),
+		Username:   username,
+		UserAgent:  defaultUserAgent,
+		HTTPClient: &http.Client{Transport: NewTransport(nil)},
+	}
+}
+
+func NewClientFromProfile() (*Client, error) {
+	profile, err := config.LoadUserProfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user profile with %v", err)
+	}
+
+	if profile.UsesApp() {
+		client := NewClient("", profile.GetUsername())
+		client.SetHost(profile.GetHost())
+		client.TokenSource = NewInstallationTokenSource(profile.GetHost(), profile.AppID, profile.InstallationID, profile.PrivateKeyPath)
+		return client, nil
+	}
+
+	cred, _, err := config.ResolveCredential()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth token with %w", err)
+	}
+
+	client := NewClient(cred.AccessToken, profile.GetUsername())
+	client.SetHost(profile.GetHost())
+	if cred.RefreshToken != "" {
+		store, err := profile.TokenStore()
+		if err != nil {
+			return nil, err
+		}
+		client.TokenSource = NewUserTokenSource(client.Host, cred, store)
+	}
+
+	// Tokens from the environment or a helper can be used without ever
+	// running `login`, so the username may not be known yet.
+	if client.Username == "" {
+		user, err := client.CurrentUser()
+		if err != nil {
+			return nil, err
+		}
+		client.Username = user.Login
+	}
+	return client, nil
+}
+
+// SetHost points the client at a GitHub Enterprise Server instance (or back at
+// github.com), updating both the REST base URL and the git remote host.
+func (c *Client) SetHost(host string) {
+	c.Host = config.NormalizeHost(host)
+	c.BaseURL = config.APIBaseURL(c.Host)
+}
+
+// resolveOwner fills in the client's user as the owner of repos given by name
+// only.
+func (c *Client) resolveOwner(repo config.RepoRef) config.RepoRef {
+	if repo.Owner == "" {
+		repo.Owner = c.Username
+	}
+	return repo
+}
+
+func (c *Client) repoPath(repo config.RepoRef) string {
+	repo = c.resolveOwner(repo)
+	return fmt.Sprintf("repos/%s/%s", repo.Owner, repo.Name)
+}
+
+// LocalPath is where repo is cloned.
+func (c *Client) LocalPath(repo config.RepoRef) string {
+	return config.LocalRepoPath(repo, c.Username)
+}
+
+func (c *Client) remoteURL(owner, repoName string) string {
+	return fmt.Sprintf("%s/%s/%s.git", config.WebURL(c.Host), owner, repoName)
+}
+
+func (c *Client) url(path string) string {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return path
+	}
+	return strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func (c *Client) newRequest(method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body with %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), method, c.url(path), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request with %v", err)
+	}
+
+	req.Header.Set("Accept", mediaTypeJSON)
+	req.Header.Set("X-GitHub-Api-Version", defaultAPIVersion)
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	token := c.Token
+	if c.TokenSource != nil {
+		if token, err = c.TokenSource.Token(); err != nil {
+			return nil, err
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+// do sends req and, if v is non-nil, decodes the JSON response body into it.
+func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		retry, rerr := c.reauthorize(req)
+		if rerr != nil {
+			resp.Body.Close()
+			return nil, rerr
+		}
+		if retry != nil {
+			resp.Body.Close()
+			resp, err = httpClient.Do(retry)
+		}
+	}
+	if err != nil {
+		var rl *RateLimitError
+		if errors.As(err, &rl) {
+			return nil, rl
+		}
+		return nil, fmt.Errorf("HTTP request failed with %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp); err != nil {
+		return resp, err
+	}
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil && err != io.EOF {
+			return resp, fmt.Errorf("failed to decode response with %v", err)
+		}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) call(method, path string, body, v interface{}) error {
+	req, err := c.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req, v)
+	return err
+}
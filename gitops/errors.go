@@ -0,0 +1,113 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrorDetail is one entry of the "errors" array GitHub attaches to 422
+// Validation Failed responses.
+type ErrorDetail struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (d ErrorDetail) String() string {
+	if d.Message != "" {
+		return d.Message
+	}
+	if d.Field != "" {
+		return fmt.Sprintf("%s.%s %s", d.Resource, d.Field, d.Code)
+	}
+	return fmt.Sprintf("%s %s", d.Resource, d.Code)
+}
+
+// APIError is any non-2xx response from the GitHub API. The more specific
+// NotFoundError, UnauthorizedError and ValidationError all unwrap to it.
+type APIError struct {
+	StatusCode       int           `json:"-"`
+	Method           string        `json:"-"`
+	URL              string        `json:"-"`
+	Message          string        `json:"message"`
+	DocumentationURL string        `json:"documentation_url"`
+	Errors           []ErrorDetail `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Errors) > 0 {
+		details := make([]string, len(e.Errors))
+		for i, d := range e.Errors {
+			details[i] = d.String()
+		}
+		msg = fmt.Sprintf("%s (%s)", msg, strings.Join(details, "; "))
+	}
+	return fmt.Sprintf("GitHub API responded with status code %d: %s", e.StatusCode, msg)
+}
+
+type NotFoundError struct{ *APIError }
+
+func (e *NotFoundError) Unwrap() error { return e.APIError }
+
+type UnauthorizedError struct{ *APIError }
+
+func (e *UnauthorizedError) Error() string {
+	return e.APIError.Error() + "; run the `login` command to re-authenticate"
+}
+
+func (e *UnauthorizedError) Unwrap() error { return e.APIError }
+
+type ValidationError struct{ *APIError }
+
+func (e *ValidationError) Unwrap() error { return e.APIError }
+
+// HasCode reports whether any of the validation errors carries code, e.g.
+// "already_exists".
+func (e *ValidationError) HasCode(code string) bool {
+	for _, d := range e.Errors {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckResponse returns nil for 2xx responses and a typed error otherwise. It
+// consumes the body of failed responses.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+
+	if rl := rateLimitFromResponse(resp); rl != nil {
+		return rl
+	}
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.URL = resp.Request.URL.String()
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, apiErr); err != nil {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &NotFoundError{apiErr}
+	case http.StatusUnauthorized:
+		return &UnauthorizedError{apiErr}
+	case http.StatusUnprocessableEntity:
+		return &ValidationError{apiErr}
+	}
+	return apiErr
+}
@@ -0,0 +1,69 @@
+package gitops
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckResponse(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.github.com/repos/me/todo/labels", nil)
+	tests := []struct {
+		status int
+		body   string
+		check  func(error) bool
+	}{
+		{404, `{"message": "Not Found"}`, func(err error) bool {
+			var e *NotFoundError
+			return errors.As(err, &e)
+		}},
+		{401, `{"message": "Bad credentials"}`, func(err error) bool {
+			var e *UnauthorizedError
+			return errors.As(err, &e) && strings.Contains(err.Error(), "login")
+		}},
+		{422, `{"message": "Validation Failed", "errors": [{"resource": "Label", "field": "name", "code": "already_exists"}]}`, func(err error) bool {
+			var e *ValidationError
+			return errors.As(err, &e) && e.HasCode("already_exists") && !e.HasCode("missing") &&
+				strings.Contains(err.Error(), "Label.name already_exists")
+		}},
+		{403, `{"message": "Resource not accessible by integration"}`, func(err error) bool {
+			var e *APIError
+			return errors.As(err, &e) && e.StatusCode == 403
+		}},
+		{429, ``, func(err error) bool {
+			var e *RateLimitError
+			return errors.As(err, &e)
+		}},
+		{500, `upstream timed out`, func(err error) bool {
+			var e *APIError
+			return errors.As(err, &e) && e.StatusCode == 500 && e.Message == "upstream timed out"
+		}},
+	}
+	for _, tt := range tests {
+		resp := newResponse(tt.status, nil, tt.body)
+		resp.Request = req
+		err := CheckResponse(resp)
+		if err == nil || !tt.check(err) {
+			t.Errorf("CheckResponse(%d %s) = %#v", tt.status, tt.body, err)
+		}
+	}
+
+	if err := CheckResponse(newResponse(204, nil, "")); err != nil {
+		t.Errorf("CheckResponse(204) = %v, want nil", err)
+	}
+}
+
+func TestAPIErrorUnwrap(t *testing.T) {
+	resp := newResponse(404, nil, `{"message": "Not Found"}`)
+	resp.Request, _ = http.NewRequest("GET", "https://api.github.com/repos/me/todo", nil)
+	err := CheckResponse(resp)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("NotFoundError does not unwrap to an APIError: %v", err)
+	}
+	if apiErr.Method != "GET" || apiErr.URL != "https://api.github.com/repos/me/todo" {
+		t.Errorf("APIError = %+v, want the request's method and URL", apiErr)
+	}
+}
@@ -1,13 +1,8 @@
 package gitops
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,39 +11,92 @@ import (
 	"time"
 )
 
-const baseUrl = "https://api.github.com"
+const (
+	baseUrl = "https://api.github.com"
 
-var (
-	tokenPath     = filepath.Join(os.Getenv("HOME"), ".go-git-it", ".token")
-	localReposDir = filepath.Join(os.Getenv("HOME"), ".go-git-it", "repos")
+	// RepoTopic and repoDescription mark the repos created by ggi, so that
+	// sync can find them on GitHub.
+	RepoTopic       = "go-git-it"
+	repoDescription = "a to-do repo generated with go-git-it (ggi): https://github.com/teriyake/go-git-it"
 )
 
 type Repo struct {
-	Owner         string   `json:"owner"`
-	ID            int64    `json:"id"`
-	NodeID        string   `json:"nodeId"`
-	Name          string   `json:"name"`
-	FullName      string   `json:"fullName"`
-	Description   string   `json:"description"`
-	Private       bool     `json:"private"`
-	LicenseInfo   *License `json:"license"`
-	DefaultBranch string   `json:"defaultBranch"`
+	Owner         *User            `json:"owner"`
+	ID            int64            `json:"id"`
+	NodeID        string           `json:"node_id"`
+	Name          string           `json:"name"`
+	FullName      string           `json:"full_name"`
+	Description   string           `json:"description"`
+	Private       bool             `json:"private"`
+	Archived      bool             `json:"archived"`
+	HasIssues     bool             `json:"has_issues"`
+	LicenseInfo   *License         `json:"license"`
+	DefaultBranch string           `json:"default_branch"`
+	Permissions   *RepoPermissions `json:"permissions,omitempty"`
+	Topics        []string         `json:"topics,omitempty"`
+}
+
+// Ref returns the owner and name of the repo.
+func (r *Repo) Ref() config.RepoRef {
+	ref := config.RepoRef{Name: r.Name}
+	if r.Owner != nil {
+		ref.Owner = r.Owner.Login
+	}
+	return ref
+}
+
+// IsToDoRepo reports whether the repo was created by ggi, going by its topic
+// or, for repos created before topics were set, its description.
+func (r *Repo) IsToDoRepo() bool {
+	for _, t := range r.Topics {
+		if t == RepoTopic {
+			return true
+		}
+	}
+	return r.Description == repoDescription
+}
+
+// RepoPermissions are the signed-in user's permissions on a repo.
+type RepoPermissions struct {
+	Admin    bool `json:"admin"`
+	Maintain bool `json:"maintain"`
+	Push     bool `json:"push"`
+	Triage   bool `json:"triage"`
+	Pull     bool `json:"pull"`
 }
 
 type License struct {
 	Key    string `json:"key"`
 	Name   string `json:"name"`
-	SpdxID string `json:"spdxId"`
+	SpdxID string `json:"spdx_id"`
 	Url    string `json:"url"`
 }
 
 type Issue struct {
-	Number    int      `json:"number"`
-	State     string   `json:"state"`
-	Title     string   `json:"title"`
-	Body      string   `json:"body"`
-	Assignees []string `json:"assignees,omitempty"`
-	Labels    []*Label `json:"labels,omitempty"`
+	Number    int        `json:"number"`
+	State     string     `json:"state"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	HTMLURL   string     `json:"html_url,omitempty"`
+	Assignees []*User    `json:"assignees,omitempty"`
+	Labels    []*Label   `json:"labels,omitempty"`
+	Milestone *Milestone `json:"milestone,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+type Milestone struct {
+	Number int        `json:"number"`
+	Title  string     `json:"title"`
+	State  string     `json:"state"`
+	DueOn  *time.Time `json:"due_on,omitempty"`
+}
+
+type User struct {
+	Login string `json:"login"`
+	Name  string `json:"name,omitempty"`
 }
 
 type Label struct {
@@ -57,19 +105,97 @@ type Label struct {
 	Color       string `json:"color"`
 }
 
-func NewLabel(status string) *Label {
-	if status == "done" {
-		return &Label{Name: status, Description: "Mark a task as done", Color: "#98971a"}
+func (i *Issue) HasLabel(name string) bool {
+	for _, l := range i.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return true
+		}
 	}
-	if status == "doing" {
-		return &Label{Name: status, Description: "Mark a task as in-progress", Color: "#d79921"}
+	return false
+}
+
+func (i *Issue) LabelNames() []string {
+	names := make([]string, 0, len(i.Labels))
+	for _, l := range i.Labels {
+		names = append(names, l.Name)
 	}
-	if status == "will-do" {
-		return &Label{Name: status, Description: "Mark a task as not-yet-started", Color: "#7c6f64"}
+	return names
+}
+
+func (i *Issue) AssigneeLogins() []string {
+	logins := make([]string, 0, len(i.Assignees))
+	for _, a := range i.Assignees {
+		logins = append(logins, a.Login)
+	}
+	return logins
+}
+
+// Meta returns the ggi metadata stored in the issue body.
+func (i *Issue) Meta() TaskMeta {
+	meta, _ := ParseTaskMeta(i.Body)
+	return meta
+}
+
+// Description returns the issue body without the ggi metadata block.
+func (i *Issue) Description() string {
+	_, text := ParseTaskMeta(i.Body)
+	return text
+}
+
+// DueOn returns the deadline of the task, if it has one. Older tasks kept
+// their deadline as the due date of a milestone named after the task.
+func (i *Issue) DueOn() *time.Time {
+	if due := i.Meta().Due(); due != nil {
+		return due
+	}
+	if i.Milestone != nil {
+		return i.Milestone.DueOn
 	}
 	return nil
 }
 
+// Priority returns the priority of the task (one of Priorities), or "" if it
+// has none.
+func (i *Issue) Priority() string {
+	return i.Meta().Priority()
+}
+
+// ByDue orders tasks by deadline, then by number. Tasks without a deadline
+// sort after those with one.
+func ByDue(a, b *Issue) bool {
+	da, db := a.DueOn(), b.DueOn()
+	switch {
+	case da == nil && db == nil:
+		return a.Number < b.Number
+	case da == nil:
+		return false
+	case db == nil:
+		return true
+	case da.Equal(*db):
+		return a.Number < b.Number
+	}
+	return da.Before(*db)
+}
+
+// ByPriority orders tasks by priority, then like ByDue. Tasks without a
+// priority sort after those with one.
+func ByPriority(a, b *Issue) bool {
+	pa, pb := priorityRank(a.Priority()), priorityRank(b.Priority())
+	if pa != pb {
+		return pa < pb
+	}
+	return ByDue(a, b)
+}
+
+func priorityRank(priority string) int {
+	for i, p := range Priorities {
+		if p == priority {
+			return i
+		}
+	}
+	return len(Priorities)
+}
+
 func IsGitRepo() bool {
 	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
 	output, err := cmd.Output()
@@ -77,514 +203,537 @@ func IsGitRepo() bool {
 }
 
 func HasToken() bool {
-	if _, err := os.Stat(tokenPath); err != nil {
-		return false
+	if profile, err := config.LoadUserProfile(); err == nil && profile.UsesApp() {
+		return true
 	}
-
-	if _, err := ioutil.ReadFile(tokenPath); err != nil {
-		return false
-	}
-
-	return true
+	_, err := config.GetToken()
+	return err == nil
 }
 
-func copyFile(src, dest string) error {
+// copyTaskFile copies src into the dest directory. Markdown and plain-text
+// files get meta merged into the ggi metadata block at their end; any other
+// file is copied unchanged, since an HTML comment would corrupt it.
+func copyTaskFile(src, dest string, meta TaskMeta) (string, error) {
 	srcInfo, err := os.Stat(src)
 	if err != nil {
-		return fmt.Errorf("invalid source path: %v", err)
+		return "", fmt.Errorf("invalid source path: %v", err)
 	}
 	if srcInfo.IsDir() {
-		return fmt.Errorf("source is a directory, not a file")
+		return "", fmt.Errorf("source is a directory, not a file")
 	}
 
-	source, err := os.Open(src)
+	data, err := os.ReadFile(src)
 	if err != nil {
-		return fmt.Errorf("invalid source file: %v", err)
+		return "", fmt.Errorf("invalid source file: %v", err)
 	}
-	defer source.Close()
 
-	dest = filepath.Join(dest, filepath.Base(src))
-	destination, err := os.Create(dest)
-	if err != nil {
-		return err
+	if embedsTaskMeta(src) {
+		existing, text := ParseTaskMeta(string(data))
+		for k, v := range meta {
+			existing[k] = v
+		}
+		out := existing.Render(text)
+		// ParseTaskMeta works on LF line endings, so put CRLF back for
+		// files that used it.
+		if strings.Contains(string(data), "\r\n") {
+			out = strings.ReplaceAll(out, "\n", "\r\n")
+		}
+		data = []byte(out)
 	}
-	defer destination.Close()
 
-	_, err = io.Copy(destination, source)
-	return err
+	dest = filepath.Join(dest, filepath.Base(src))
+	if err := os.WriteFile(dest, data, srcInfo.Mode().Perm()); err != nil {
+		return "", err
+	}
+	return dest, nil
 }
 
-func AddAndCommit(filename string, message string) error {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
+// embedsTaskMeta reports whether the task file at path can carry a metadata
+// block, which is only the case for Markdown and plain text.
+func embedsTaskMeta(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".txt":
+		return true
 	}
+	return false
+}
 
-	repoName := profile.GetCurrentRepo()
-	repoPath := filepath.Join(os.Getenv("HOME"), ".go-git-it", "repos", repoName)
-
-	err = copyFile(filename, repoPath)
-	if err != nil {
-		return fmt.Errorf("failed to copy task file to repo with %v", err)
+// AddAndCommit copies a task file into the local clone at repoPath, records
+// meta (such as the number of the task's issue) in it if it is Markdown or
+// plain text, then commits and pushes it. It returns the new commit.
+func AddAndCommit(repoPath, filename, message string, meta TaskMeta) (string, error) {
+	if _, err := copyTaskFile(filename, repoPath, meta); err != nil {
+		return "", fmt.Errorf("failed to copy task file to repo with %v", err)
 	}
 
 	_, fileName := filepath.Split(filename)
 	addCmd := exec.Command("git", "-C", repoPath, "add", fileName)
 	if out, err := addCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git add %s failed with %v and output: %v", fileName, err, string(out))
+		return "", fmt.Errorf("git add %s failed with %v and output: %v", fileName, err, string(out))
 	}
 	commitCmd := exec.Command("git", "-C", repoPath, "commit", "-m", message)
 	if out, err := commitCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git commit failed with %v and output: %v", err, string(out))
+		return "", fmt.Errorf("git commit failed with %v and output: %v", err, string(out))
+	}
+	revCmd := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD")
+	out, err := revCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed with %v", err)
 	}
 	pushCmd := exec.Command("git", "-C", repoPath, "push")
 	if err := pushCmd.Run(); err != nil {
-		return fmt.Errorf("git push failed with %v", err)
+		return "", fmt.Errorf("git push failed with %v", err)
 	}
 
-	return nil
+	return strings.TrimSpace(string(out)), nil
 }
 
-func CreateNewRepo(repoName string, privacy bool) error {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
-	}
-	username := profile.GetUsername()
-
-	token, e := config.GetToken()
-	if e != nil {
-		return fmt.Errorf("failed to get auth token with %v", e)
-	}
-
-	urlStr := strings.Join([]string{baseUrl, "user", "repos"}, "/")
-	var vis string
-	if privacy {
-		vis = "private"
-	} else {
-		vis = "public"
-	}
-
+// CreateNewRepo creates repo on GitHub and clones it. Repos owned by anyone
+// but the signed-in user are created in the organization named by the owner.
+func (c *Client) CreateNewRepo(repo config.RepoRef, privacy bool) error {
 	reqBody := map[string]interface{}{
-		"name":                repoName,
-		"description":         "a to-do repo generated with go-git-it (ggi): https://github.com/teriyake/go-git-it",
-		"homepage":            "",
-		"auto_init":           true,
-		"readme":              "default",
-		"visibility":          vis,
-		"hasIssues":           true,
-		"hasProjects":         true,
-		"hasWiki":             true,
-		"isTemplate":          false,
-		"allowSquashMerge":    true,
-		"allowRebaseMerge":    false,
-		"deleteBranchOnMerge": false,
-		"licenseTemplate":     "MIT",
-	}
-
-	bodyBytes, _ := json.Marshal(reqBody)
-
-	client := &http.Client{}
-	request, err := http.NewRequestWithContext(context.Background(), "POST", urlStr, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create http request with %v", err)
-	}
-
-	request.Header.Set("Authorization", "token "+token)
-	response, err := client.Do(request)
-	defer response.Body.Close()
-
-	if err != nil || response.StatusCode >= 400 {
-		data, _ := ioutil.ReadAll(response.Body)
-		return fmt.Errorf("http request failed with %v\nresponse received: %v\n", err, string(data))
+		"name":                   repo.Name,
+		"description":            repoDescription,
+		"homepage":               "",
+		"auto_init":              true,
+		"private":                privacy,
+		"has_issues":             true,
+		"has_projects":           true,
+		"has_wiki":               true,
+		"is_template":            false,
+		"allow_squash_merge":     true,
+		"allow_rebase_merge":     false,
+		"delete_branch_on_merge": false,
+		"license_template":       "mit",
+	}
+
+	repo = c.resolveOwner(repo)
+	// An app installation can only create repos in the organization it is
+	// installed on, never through /user/repos.
+	_, isApp := c.TokenSource.(*InstallationTokenSource)
+	path := "user/repos"
+	if isApp || !strings.EqualFold(repo.Owner, c.Username) {
+		path = fmt.Sprintf("orgs/%s/repos", repo.Owner)
+	}
+
+	var created Repo
+	if err := c.call("POST", path, reqBody, &created); err != nil {
+		return err
 	}
-
-	respData := make(map[string]interface{}, 0)
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&respData)
-	if err != nil {
-		return fmt.Errorf("failed to decode json response with %v", err)
+	if err := c.SetTopics(repo, []string{RepoTopic}); err != nil {
+		return fmt.Errorf("repo %s was created but setting its topic failed: %w", repo, err)
 	}
 
-	targetDir := filepath.Join(os.Getenv("HOME"), ".go-git-it", "repos", repoName)
+	targetDir := c.LocalPath(repo)
 
 	if _, err := os.Stat(targetDir); !os.IsNotExist(err) {
 		return fmt.Errorf("target directory %s already exists", targetDir)
 	}
 
+	return c.clone(repo, targetDir)
+}
+
+func (c *Client) clone(repo config.RepoRef, targetDir string) error {
 	if err := os.MkdirAll(filepath.Dir(targetDir), 0755); err != nil {
 		return fmt.Errorf("unable to create parent directories for %s: %w", targetDir, err)
 	}
 
-	remoteURL := fmt.Sprintf("https://github.com/%s/%s.git", username, repoName)
-	cmd := exec.Command("git", "clone", remoteURL, targetDir)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git clone failed with %v", err)
+	cmd := exec.Command("git", "clone", c.remoteURL(repo.Owner, repo.Name), targetDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone failed with %v and output: %v", err, string(out))
 	}
-
-	fmt.Printf("Repository cloned successfully into %s\n", targetDir)
-
 	return nil
 }
 
-func DeleteRemoteRepo(repoName string) error {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
-	}
-	username := profile.Username
+// CloneRepo clones repo into its local path, or reuses an existing clone
+// there if its origin is repo. It reports whether a clone was reused.
+func (c *Client) CloneRepo(repo config.RepoRef) (bool, error) {
+	repo = c.resolveOwner(repo)
+	targetDir := c.LocalPath(repo)
 
-	token, err := config.GetToken()
-	if err != nil {
-		return fmt.Errorf("failed to get auth token with %v", err)
+	if _, err := os.Stat(targetDir); os.IsNotExist(err) {
+		return false, c.clone(repo, targetDir)
 	}
 
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s", username, repoName)
-
-	client := &http.Client{}
-	request, err := http.NewRequestWithContext(context.Background(), "DELETE", urlStr, nil)
+	out, err := exec.Command("git", "-C", targetDir, "remote", "get-url", "origin").Output()
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request with %v", err)
+		return false, fmt.Errorf("%s already exists but is not a clone of %s", targetDir, repo)
 	}
-
-	request.Header.Set("Authorization", "token "+token)
-	response, err := client.Do(request)
-	if err != nil {
-		return fmt.Errorf("HTTP request failed with %v\nresponse received:\n%v\n", err, response)
+	origin := strings.TrimSpace(string(out))
+	if existing, err := remoteRepo(origin); err != nil || !existing.Equal(repo) {
+		return false, fmt.Errorf("%s already exists but is a clone of %s", targetDir, origin)
 	}
-	defer response.Body.Close()
+	return true, nil
+}
 
-	if response.StatusCode >= 400 {
-		data, _ := ioutil.ReadAll(response.Body)
-		return fmt.Errorf("GitHub API responded with status code %d: %s", response.StatusCode, string(data))
+// remoteRepo extracts the repo from a git remote URL, given either as
+// https://host/owner/name.git or in the SSH form git@host:owner/name.git.
+func remoteRepo(remote string) (config.RepoRef, error) {
+	path := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(remote), "/"), ".git")
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+len("://"):]
 	}
+	path = path[strings.LastIndex(path, ":")+1:]
 
-	return nil
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return config.RepoRef{}, fmt.Errorf("cannot find the repo in remote %s", remote)
+	}
+	return config.ParseRepoRef(parts[len(parts)-2]+"/"+parts[len(parts)-1], "")
 }
 
-func CreateMilestone(token, owner, repo, title, dueDate string) (int, error) {
-
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/milestones", owner, repo)
-
-	requestBody, err := json.Marshal(map[string]string{
-		"title":  title,
-		"due_on": dueDate,
-	})
-	if err != nil {
-		return 0, err
-	}
+// SetTopics replaces the topics of repo.
+func (c *Client) SetTopics(repo config.RepoRef, topics []string) error {
+	return c.call("PUT", c.repoPath(repo)+"/topics", map[string][]string{"names": topics}, nil)
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return 0, err
+// PullRebase brings the clone at repoPath up to date with its upstream,
+// rebasing any local commits on top.
+func PullRebase(repoPath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "pull", "--rebase", "--autostash")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git pull failed with %v and output: %v", err, strings.TrimSpace(string(out)))
 	}
-	req.Header.Set("Authorization", "token "+token)
-	req.Header.Set("Content-Type", "application/json")
+	return nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, err
+// RevertCommit reverts commit in the local clone at repoPath and pushes the
+// revert, after bringing the clone up to date.
+func RevertCommit(repoPath, commit string) error {
+	if err := PullRebase(repoPath); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 201 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to create milestone: %s", string(body))
+	revertCmd := exec.Command("git", "-C", repoPath, "revert", "--no-edit", commit)
+	if out, err := revertCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git revert failed with %v and output: %v", err, strings.TrimSpace(string(out)))
 	}
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-	if idFloat, ok := result["number"].(float64); ok {
-		return int(idFloat), nil
+	pushCmd := exec.Command("git", "-C", repoPath, "push")
+	if err := pushCmd.Run(); err != nil {
+		return fmt.Errorf("git push failed with %v", err)
 	}
-
-	return 0, fmt.Errorf("could not parse milestone ID")
+	return nil
 }
 
-func CreateIssueWithMilestone(token, username, repoName, issueTitle, issueBody string, milestoneNumber int) error {
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues", username, repoName)
-	issueData := map[string]interface{}{
-		"title":     issueTitle,
-		"body":      issueBody,
-		"milestone": milestoneNumber,
+func (c *Client) GetRepo(repo config.RepoRef) (*Repo, error) {
+	var r Repo
+	if err := c.call("GET", c.repoPath(repo), nil, &r); err != nil {
+		return nil, err
 	}
+	return &r, nil
+}
 
-	data, _ := json.Marshal(issueData)
-
-	request, err := http.NewRequestWithContext(context.Background(), "POST", urlStr, bytes.NewReader(data))
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request with %v", err)
-	}
-
-	request.Header.Set("Authorization", "token "+token)
-	request.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		return fmt.Errorf("HTTP request failed with %v", err)
+// CreateLabel adds label to repo. GitHub expects the color without a leading
+// '#'.
+func (c *Client) CreateLabel(repo config.RepoRef, label *Label) error {
+	body := map[string]string{
+		"name":        label.Name,
+		"description": label.Description,
+		"color":       strings.TrimPrefix(label.Color, "#"),
 	}
-	defer response.Body.Close()
+	return c.call("POST", c.repoPath(repo)+"/labels", body, nil)
+}
 
-	if response.StatusCode >= 400 {
-		body, _ := ioutil.ReadAll(response.Body)
-		return fmt.Errorf("GitHub API responded with status code %d: %s", response.StatusCode, string(body))
+// UpdateLabel replaces the name, color and description of the label
+// currently called name.
+func (c *Client) UpdateLabel(repo config.RepoRef, name string, label *Label) error {
+	body := map[string]string{
+		"new_name":    label.Name,
+		"description": label.Description,
+		"color":       strings.TrimPrefix(label.Color, "#"),
 	}
-
-	return nil
+	return c.call("PATCH", c.repoPath(repo)+"/labels/"+url.PathEscape(name), body, nil)
 }
 
-func SetDeadline(taskDescription, deadlineStr string) error {
-	profile, err := config.LoadUserProfile()
+// ProvisionStatusLabels creates the labels of w's states that repo is missing
+// and returns the names of the ones it created. Existing labels are left as
+// they are, see SyncStatusLabels.
+func (c *Client) ProvisionStatusLabels(repo config.RepoRef, w *Workflow) ([]string, error) {
+	existing, err := c.ListLabels(repo)
 	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
+		return nil, err
 	}
 
-	token, err := config.GetToken()
-	if err != nil {
-		return fmt.Errorf("failed to get GitHub token with %v", err)
+	var created []string
+	for _, status := range w.Names() {
+		if findLabel(existing, status) != nil {
+			continue
+		}
+		if err := c.CreateLabel(repo, w.Label(status)); err != nil {
+			return created, fmt.Errorf("failed to create label %s with %w", status, err)
+		}
+		created = append(created, status)
 	}
+	return created, nil
+}
 
-	username := profile.GetUsername()
-	repoName := profile.GetCurrentRepo()
+const (
+	LabelInSync  = "in-sync"
+	LabelCreated = "created"
+	LabelUpdated = "updated"
+)
 
-	parsedDeadline, err := time.Parse("2006-01-02", deadlineStr)
-	if err != nil {
-		return fmt.Errorf("invalid deadline format: %v", err)
-	}
-	deadline := fmt.Sprintf("%sT00:00:00Z", parsedDeadline.Format("2006-01-02"))
+// LabelSync is what SyncStatusLabels found for one status label. Drift lists
+// how the label in the repo differs from the workflow, e.g. "color ededed".
+type LabelSync struct {
+	Name   string
+	Action string
+	Drift  []string
+}
 
-	milestoneID, err := CreateMilestone(token, username, repoName, taskDescription, deadline)
-	if err != nil {
-		return fmt.Errorf("failed to create milestone with %v", err)
+// SyncStatusLabels creates the labels of w's states that repo is missing and
+// resets the name, color and description of the ones that drifted from w.
+// With dryRun, nothing is changed and Action says what would have been done.
+func (c *Client) SyncStatusLabels(repo config.RepoRef, w *Workflow, dryRun bool) ([]LabelSync, error) {
+	existing, err := c.ListLabels(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]LabelSync, 0, len(w.States))
+	for _, status := range w.Names() {
+		want := w.Label(status)
+		have := findLabel(existing, status)
+		result := LabelSync{Name: status, Action: LabelInSync}
+
+		switch {
+		case have == nil:
+			result.Action = LabelCreated
+			result.Drift = []string{"missing"}
+			if !dryRun {
+				if err := c.CreateLabel(repo, want); err != nil {
+					return results, fmt.Errorf("failed to create label %s with %w", status, err)
+				}
+			}
+		default:
+			result.Drift = labelDrift(have, want)
+			if len(result.Drift) == 0 {
+				break
+			}
+			result.Action = LabelUpdated
+			if !dryRun {
+				if err := c.UpdateLabel(repo, have.Name, want); err != nil {
+					return results, fmt.Errorf("failed to update label %s with %w", status, err)
+				}
+			}
+		}
+		results = append(results, result)
 	}
+	return results, nil
+}
 
-	issueBody := fmt.Sprintf("This task is due on %s", deadline)
-	if err := CreateIssueWithMilestone(token, username, repoName, taskDescription, issueBody, milestoneID); err != nil {
-		return fmt.Errorf("failed to create issue with milestone with %v", err)
+// findLabel looks up a label by name. Label names are case-insensitive on
+// GitHub.
+func findLabel(labels []Label, name string) *Label {
+	for i := range labels {
+		if strings.EqualFold(labels[i].Name, name) {
+			return &labels[i]
+		}
 	}
-
-	fmt.Printf("Milestone %v successfully set!\n", milestoneID)
 	return nil
 }
 
-func ChangeIssueLabel(repoName string, issueNumber int, labels []string) error {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
+func labelDrift(have, want *Label) []string {
+	var drift []string
+	if have.Name != want.Name {
+		drift = append(drift, "name "+have.Name)
 	}
-
-	token, err := config.GetToken()
-	if err != nil {
-		return fmt.Errorf("failed to get auth token with %v", err)
+	if !strings.EqualFold(strings.TrimPrefix(have.Color, "#"), strings.TrimPrefix(want.Color, "#")) {
+		drift = append(drift, "color "+have.Color)
 	}
-
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d/labels", profile.Username, repoName, issueNumber)
-	requestBody := map[string][]string{
-		"labels": labels,
+	if have.Description != want.Description {
+		drift = append(drift, fmt.Sprintf("description %q", have.Description))
 	}
-	data, _ := json.Marshal(requestBody)
+	return drift
+}
 
-	client := &http.Client{}
-	request, err := http.NewRequestWithContext(context.Background(), "PUT", urlStr, bytes.NewReader(data))
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request with %v", err)
-	}
+func (c *Client) DeleteRemoteRepo(repo config.RepoRef) error {
+	return c.call("DELETE", c.repoPath(repo), nil, nil)
+}
 
-	request.Header.Set("Authorization", "Bearer "+token)
-	request.Header.Set("Content-Type", "application/json")
+// SetIssueDeadline stores the deadline of an existing task in its metadata,
+// or clears it when deadline is nil. Tasks created before deadlines moved into
+// the issue body are detached from their one-issue milestone at the same time.
+func (c *Client) SetIssueDeadline(repo config.RepoRef, issue *Issue, deadline *time.Time) (*Issue, error) {
+	meta, text := ParseTaskMeta(issue.Body)
+	meta.SetDue(deadline)
 
-	response, err := client.Do(request)
-	if err != nil {
-		return fmt.Errorf("HTTP request failed with %v", err)
+	fields := map[string]interface{}{
+		"body": meta.Render(text),
 	}
-	defer response.Body.Close()
-
-	if response.StatusCode >= 400 {
-		body, _ := ioutil.ReadAll(response.Body)
-		return fmt.Errorf("GitHub API responded with status code %d: %s", response.StatusCode, string(body))
+	if issue.Milestone != nil && issue.Milestone.Title == issue.Title {
+		fields["milestone"] = nil
 	}
 
-	return nil
+	return c.EditIssue(repo, issue.Number, fields)
 }
 
-func ListIssues(repoName string) ([]Issue, error) {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load user profile with %v", err)
-	}
-	username := profile.GetUsername()
+// SetIssuePriority stores the priority of an existing task in its metadata,
+// or clears it when priority is "".
+func (c *Client) SetIssuePriority(repo config.RepoRef, issue *Issue, priority string) (*Issue, error) {
+	meta, text := ParseTaskMeta(issue.Body)
+	meta.SetPriority(priority)
 
-	token, err := config.GetToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get auth token with %v", err)
-	}
+	return c.EditIssue(repo, issue.Number, map[string]interface{}{
+		"body": meta.Render(text),
+	})
+}
 
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues?state=all", username, repoName)
-	client := &http.Client{}
-	request, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request with %v", err)
+func (c *Client) CreateIssue(repo config.RepoRef, issueTitle, issueBody string) (*Issue, error) {
+	issueData := map[string]interface{}{
+		"title": issueTitle,
+		"body":  issueBody,
 	}
 
-	request.Header.Set("Authorization", "Bearer "+token)
-
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed with %v", err)
+	var issue Issue
+	if err := c.call("POST", c.repoPath(repo)+"/issues", issueData, &issue); err != nil {
+		return nil, err
 	}
-	defer response.Body.Close()
+	return &issue, nil
+}
 
-	if response.StatusCode >= 400 {
-		body, _ := ioutil.ReadAll(response.Body)
-		return nil, fmt.Errorf("GitHub API responded with status code %d: %s", response.StatusCode, string(body))
+// EditIssue updates the given fields (title, body, state, milestone, ...) of
+// an issue and returns the updated issue.
+func (c *Client) EditIssue(repo config.RepoRef, issueNumber int, fields map[string]interface{}) (*Issue, error) {
+	var issue Issue
+	if err := c.call("PATCH", fmt.Sprintf("%s/issues/%d", c.repoPath(repo), issueNumber), fields, &issue); err != nil {
+		return nil, err
 	}
+	return &issue, nil
+}
 
-	var issues []Issue
-	if err := json.NewDecoder(response.Body).Decode(&issues); err != nil {
-		return nil, fmt.Errorf("failed to decode response with %v", err)
+// AddIssueLabels adds labels to an issue, keeping the ones it already has,
+// and returns all of its labels.
+func (c *Client) AddIssueLabels(repo config.RepoRef, issueNumber int, labels []string) ([]*Label, error) {
+	requestBody := map[string][]string{
+		"labels": labels,
 	}
 
-	var openIssues []Issue
-	for _, issue := range issues {
-		if issue.State == "open" {
-			openIssues = append(openIssues, issue)
-		}
+	var result []*Label
+	if err := c.call("POST", fmt.Sprintf("%s/issues/%d/labels", c.repoPath(repo), issueNumber), requestBody, &result); err != nil {
+		return nil, err
 	}
-
-	return openIssues, nil
+	return result, nil
 }
 
-func CloseIssue(repoName string, issueNumber int) error {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
+// RemoveIssueLabel removes one label from an issue and returns the labels it
+// has left.
+func (c *Client) RemoveIssueLabel(repo config.RepoRef, issueNumber int, label string) ([]*Label, error) {
+	var result []*Label
+	if err := c.call("DELETE", fmt.Sprintf("%s/issues/%d/labels/%s", c.repoPath(repo), issueNumber, url.PathEscape(label)), nil, &result); err != nil {
+		return nil, err
 	}
-	username := profile.GetUsername()
+	return result, nil
+}
 
-	token, err := config.GetToken()
-	if err != nil {
-		return fmt.Errorf("failed to get auth toke with: %v", err)
+func (c *Client) GetIssue(repo config.RepoRef, issueNumber int) (*Issue, error) {
+	var issue Issue
+	if err := c.call("GET", fmt.Sprintf("%s/issues/%d", c.repoPath(repo), issueNumber), nil, &issue); err != nil {
+		return nil, err
 	}
+	return &issue, nil
+}
 
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d", username, repoName, issueNumber)
-	body := map[string]string{
+func (c *Client) CloseIssue(repo config.RepoRef, issueNumber int) (*Issue, error) {
+	return c.EditIssue(repo, issueNumber, map[string]interface{}{
 		"state": "closed",
-	}
-	data, _ := json.Marshal(body)
-
-	request, err := http.NewRequest("PATCH", urlStr, bytes.NewReader(data))
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request with %v", err)
-	}
-
-	request.Header.Set("Authorization", "Bearer "+token)
-	request.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		return fmt.Errorf("HTTP request failed with %v", err)
-	}
-	defer response.Body.Close()
+	})
+}
 
-	if response.StatusCode >= 400 {
-		body, _ := ioutil.ReadAll(response.Body)
-		return fmt.Errorf("GitHub API responded with status code %d: %s", response.StatusCode, string(body))
-	}
+// DiscardIssue closes an issue as not planned, which is as close to deleting
+// it as the REST API allows.
+func (c *Client) DiscardIssue(repo config.RepoRef, issueNumber int) (*Issue, error) {
+	return c.EditIssue(repo, issueNumber, map[string]interface{}{
+		"state":        "closed",
+		"state_reason": "not_planned",
+	})
+}
 
-	return nil
+func (c *Client) ReopenIssue(repo config.RepoRef, issueNumber int) (*Issue, error) {
+	return c.EditIssue(repo, issueNumber, map[string]interface{}{
+		"state": "open",
+	})
 }
 
-func GetFileSHA(repoName, filePath string) (string, error) {
-	profile, err := config.LoadUserProfile()
+// RestoreIssue puts an issue back into the given state ("open" or "closed")
+// with exactly the given labels, adding and removing only the labels that
+// differ.
+func (c *Client) RestoreIssue(repo config.RepoRef, issueNumber int, state string, labels []string) (*Issue, error) {
+	issue, err := c.GetIssue(repo, issueNumber)
 	if err != nil {
-		return "", fmt.Errorf("failed to load user profile with %v", err)
+		return nil, err
 	}
-	username := profile.GetUsername()
 
-	token, err := config.GetToken()
-	if err != nil {
-		return "", fmt.Errorf("failed to get auth token with %v", err)
+	current := issue.LabelNames()
+	var missing []string
+	for _, name := range labels {
+		if !issue.HasLabel(name) {
+			missing = append(missing, name)
+		}
 	}
-
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", username, repoName, filePath)
-
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return "", fmt.Errorf("creating file SHA request failed with %v", err)
+	if len(missing) > 0 {
+		if issue.Labels, err = c.AddIssueLabels(repo, issueNumber, missing); err != nil {
+			return nil, err
+		}
 	}
-
-	req.Header.Set("Authorization", "token "+token)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("HTTP request failed with %v", err)
+	for _, l := range current {
+		keep := false
+		for _, name := range labels {
+			if strings.EqualFold(l, name) {
+				keep = true
+				break
+			}
+		}
+		if keep {
+			continue
+		}
+		if issue.Labels, err = c.RemoveIssueLabel(repo, issueNumber, l); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("GitHub API responded with status code %d", resp.StatusCode)
+	if state != "" && state != issue.State {
+		return c.EditIssue(repo, issueNumber, map[string]interface{}{"state": state})
 	}
+	return issue, nil
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("decoding response failed with %v", err)
+func (c *Client) GetFileSHA(repo config.RepoRef, filePath string) (string, error) {
+	var result struct {
+		SHA string `json:"sha"`
 	}
-
-	sha, ok := result["sha"].(string)
-	if !ok {
+	if err := c.call("GET", fmt.Sprintf("%s/contents/%s", c.repoPath(repo), filePath), nil, &result); err != nil {
+		return "", err
+	}
+	if result.SHA == "" {
 		return "", fmt.Errorf("SHA not found in response")
 	}
 
-	return sha, nil
+	return result.SHA, nil
 }
 
-func DeleteRemoteFile(repoName, filePath, sha string) error {
-	profile, err := config.LoadUserProfile()
-	if err != nil {
-		return fmt.Errorf("failed to load user profile with %v", err)
-	}
-	username := profile.GetUsername()
-
-	token, err := config.GetToken()
-	if err != nil {
-		return fmt.Errorf("failed to get auth token with %v", err)
-	}
-
-	urlStr := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", username, repoName, filePath)
-	message := fmt.Sprintf("Delete task file %s", filePath)
+// DeleteRemoteFile deletes a file from repo and returns the SHA of the
+// commit that deleted it.
+func (c *Client) DeleteRemoteFile(repo config.RepoRef, filePath, sha string) (string, error) {
 	requestBody := map[string]string{
-		"message": message,
+		"message": fmt.Sprintf("Delete task file %s", filePath),
 		"sha":     sha,
 	}
-	bodyBytes, err := json.Marshal(requestBody)
-	if err != nil {
-		return fmt.Errorf("marshaling request body failed: %v", err)
-	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest("DELETE", urlStr, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return fmt.Errorf("creating delete file request failed with %v", err)
+	var result struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
 	}
-
-	req.Header.Set("Authorization", "token "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("HTTP request failed with %v", err)
+	if err := c.call("DELETE", fmt.Sprintf("%s/contents/%s", c.repoPath(repo), filePath), requestBody, &result); err != nil {
+		return "", err
 	}
-	defer resp.Body.Close()
+	return result.Commit.SHA, nil
+}
 
-	if resp.StatusCode >= 400 {
-		responseBody, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API responded with status code %d: %s", resp.StatusCode, string(responseBody))
+func (c *Client) CurrentUser() (*User, error) {
+	var user User
+	if err := c.call("GET", "user", nil, &user); err != nil {
+		return nil, err
 	}
-
-	fmt.Printf("Deleted %s remotely.\n", filePath)
-	return nil
+	return &user, nil
 }
@@ -0,0 +1,70 @@
+package gitops
+
+import (
+	"os"
+	"path/filepath"
+	"teriyake/go-git-it/config"
+	"testing"
+)
+
+func TestRemoteRepo(t *testing.T) {
+	want := config.RepoRef{Owner: "acme", Name: "todo"}
+	tests := []string{
+		"https://github.com/acme/todo.git",
+		"https://github.com/acme/todo",
+		"https://github.com/acme/todo/",
+		"https://ghe.example.com:8443/acme/todo.git",
+		"git@github.com:acme/todo.git",
+		"git@github.com:acme/todo",
+		"ssh://git@github.com/acme/todo.git",
+		"ssh://git@ghe.example.com:2222/acme/todo.git",
+	}
+	for _, remote := range tests {
+		got, err := remoteRepo(remote)
+		if err != nil {
+			t.Errorf("remoteRepo(%q) returned error: %v", remote, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("remoteRepo(%q) = %v, want %v", remote, got, want)
+		}
+	}
+
+	if _, err := remoteRepo("todo"); err == nil {
+		t.Errorf("remoteRepo of a remote without an owner should fail")
+	}
+}
+
+func TestCopyTaskFile(t *testing.T) {
+	src, dest := t.TempDir(), t.TempDir()
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"notes.md", "Buy milk\n", "Buy milk\n\n<!-- ggi\nissue: 3\n-->\n"},
+		{"notes.TXT", "Buy milk\n\n<!-- ggi\ndue: 2024-03-01\n-->\n", "Buy milk\n\n<!-- ggi\ndue: 2024-03-01\nissue: 3\n-->\n"},
+		{"crlf.md", "Buy milk\r\n", "Buy milk\r\n\r\n<!-- ggi\r\nissue: 3\r\n-->\r\n"},
+		{"list.json", "{\"items\": []}\n", "{\"items\": []}\n"},
+		{"script.sh", "#!/bin/sh\necho hi\n", "#!/bin/sh\necho hi\n"},
+		{"Makefile", "all:\n\tgo build\n", "all:\n\tgo build\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(src, tt.name)
+		if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := copyTaskFile(path, dest, TaskMeta{"issue": "3"})
+		if err != nil {
+			t.Errorf("copyTaskFile(%s) returned error: %v", tt.name, err)
+			continue
+		}
+		got, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("copyTaskFile(%s) wrote %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package gitops
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// TaskMeta is structured task data that ggi keeps in a hidden HTML comment at
+// the end of an issue body, e.g.
+//
+//	<!-- ggi
+//	due: 2024-03-01
+//	-->
+//
+// GitHub does not render the comment, so the issue still reads normally in
+// the web UI.
+type TaskMeta map[string]string
+
+var metaBlock = regexp.MustCompile(`(?s)\n*<!-- ggi\n(.*?)-->[ \t]*(\n+|$)`)
+
+// ParseTaskMeta splits an issue body into its metadata and the remaining,
+// human-written text. The block is found anywhere in the body, since people
+// editing the issue on GitHub may add text below it, and line endings are
+// normalized to "\n" as the web UI saves bodies with "\r\n".
+func ParseTaskMeta(body string) (TaskMeta, string) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	meta := TaskMeta{}
+	match := metaBlock.FindStringSubmatchIndex(body)
+	if match == nil {
+		return meta, body
+	}
+
+	for _, line := range strings.Split(body[match[2]:match[3]], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if key != "" && value != "" {
+			meta[key] = value
+		}
+	}
+
+	text, rest := body[:match[0]], body[match[1]:]
+	if rest != "" {
+		if text != "" {
+			text += "\n\n"
+		}
+		text += rest
+	}
+	return meta, text
+}
+
+// Render appends the metadata block to text. Keys are written in sorted
+// order so that rewriting an unchanged body does not produce a diff.
+func (m TaskMeta) Render(text string) string {
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return text
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(text, "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("<!-- ggi\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s: %s\n", k, m[k])
+	}
+	b.WriteString("-->\n")
+	return b.String()
+}
+
+func (m TaskMeta) Due() *time.Time {
+	due, err := time.Parse(dateLayout, m["due"])
+	if err != nil {
+		return nil
+	}
+	return &due
+}
+
+// SetDue stores the deadline, or removes it when due is nil.
+func (m TaskMeta) SetDue(due *time.Time) {
+	if due == nil {
+		delete(m, "due")
+		return
+	}
+	m["due"] = due.Format(dateLayout)
+}
+
+// Priorities are the priority levels of a task, from most to least urgent.
+var Priorities = []string{"P0", "P1", "P2", "P3"}
+
+var priorityNames = map[string]string{
+	"urgent": "P0",
+	"high":   "P1",
+	"medium": "P2",
+	"med":    "P2",
+	"low":    "P3",
+}
+
+func (m TaskMeta) Priority() string {
+	p, err := ParsePriority(m["priority"])
+	if err != nil {
+		return ""
+	}
+	return p
+}
+
+// SetPriority stores the priority, or removes it when priority is "".
+func (m TaskMeta) SetPriority(priority string) {
+	if priority == "" {
+		delete(m, "priority")
+		return
+	}
+	m["priority"] = priority
+}
+
+// ParsePriority parses a priority given on the command line as P0-P3 or as
+// urgent, high, medium (med) or low, which map to P0-P3.
+func ParsePriority(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if p, ok := priorityNames[name]; ok {
+		return p, nil
+	}
+	for _, p := range Priorities {
+		if strings.EqualFold(name, p) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid priority %q, expected one of %s or urgent, high, medium, low", s, strings.Join(Priorities, ", "))
+}
+
+// ParseDeadline parses a deadline given on the command line as YYYY-MM-DD.
+func ParseDeadline(deadlineStr string) (time.Time, error) {
+	deadline, err := time.Parse(dateLayout, deadlineStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline format, expected YYYY-MM-DD: %v", err)
+	}
+	return deadline, nil
+}
@@ -0,0 +1,86 @@
+package gitops
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTaskMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		meta TaskMeta
+		text string
+	}{
+		{
+			name: "no block",
+			body: "Buy milk",
+			meta: TaskMeta{},
+			text: "Buy milk",
+		},
+		{
+			name: "block at the end",
+			body: "Buy milk\n\n<!-- ggi\ndue: 2024-03-01\npriority: P1\n-->\n",
+			meta: TaskMeta{"due": "2024-03-01", "priority": "P1"},
+			text: "Buy milk",
+		},
+		{
+			name: "only a block",
+			body: "<!-- ggi\ndue: 2024-03-01\n-->\n",
+			meta: TaskMeta{"due": "2024-03-01"},
+			text: "",
+		},
+		{
+			name: "crlf line endings",
+			body: "Buy milk\r\n\r\n<!-- ggi\r\ndue: 2024-03-01\r\npriority: P1\r\n-->\r\n",
+			meta: TaskMeta{"due": "2024-03-01", "priority": "P1"},
+			text: "Buy milk",
+		},
+		{
+			name: "text after the block",
+			body: "Buy milk\n\n<!-- ggi\ndue: 2024-03-01\n-->\n\nFrom the store on the corner.",
+			meta: TaskMeta{"due": "2024-03-01"},
+			text: "Buy milk\n\nFrom the store on the corner.",
+		},
+		{
+			name: "crlf with text after the block",
+			body: "Buy milk\r\n\r\n<!-- ggi\r\ndue: 2024-03-01\r\n-->\r\nOat, not dairy.",
+			meta: TaskMeta{"due": "2024-03-01"},
+			text: "Buy milk\n\nOat, not dairy.",
+		},
+		{
+			name: "blank and malformed lines",
+			body: "<!-- ggi\n\nnot a field\ndue:\n file : notes.md \n-->",
+			meta: TaskMeta{"file": "notes.md"},
+			text: "",
+		},
+	}
+	for _, tt := range tests {
+		meta, text := ParseTaskMeta(tt.body)
+		if !reflect.DeepEqual(meta, tt.meta) {
+			t.Errorf("%s: meta = %v, want %v", tt.name, meta, tt.meta)
+		}
+		if text != tt.text {
+			t.Errorf("%s: text = %q, want %q", tt.name, text, tt.text)
+		}
+	}
+}
+
+func TestRenderTaskMeta(t *testing.T) {
+	meta := TaskMeta{"priority": "P1", "due": "2024-03-01", "file": ""}
+	want := "Buy milk\n\n<!-- ggi\ndue: 2024-03-01\npriority: P1\n-->\n"
+	if got := meta.Render("Buy milk\n"); got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+	if got := (TaskMeta{}).Render("Buy milk"); got != "Buy milk" {
+		t.Errorf("Render without metadata = %q, want the text unchanged", got)
+	}
+
+	parsed, text := ParseTaskMeta(want)
+	if text != "Buy milk" || parsed["due"] != "2024-03-01" || parsed["priority"] != "P1" {
+		t.Errorf("ParseTaskMeta(Render) = %v, %q", parsed, text)
+	}
+	if got := parsed.Render(text); got != want {
+		t.Errorf("re-rendering a parsed body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"teriyake/go-git-it/config"
+)
+
+const defaultPerPage = 100
+
+type ListOptions struct {
+	PerPage int
+	Page    int
+}
+
+func (o ListOptions) encode(q url.Values) {
+	perPage := o.PerPage
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	q.Set("per_page", strconv.Itoa(perPage))
+	if o.Page > 0 {
+		q.Set("page", strconv.Itoa(o.Page))
+	}
+}
+
+// PageIterator walks a paginated GitHub list endpoint one item at a time,
+// following the Link header and fetching each page only when it is needed.
+//
+//	it := client.Issues("todo", nil)
+//	for it.Next() {
+//		issue := it.Value()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type PageIterator[T any] struct {
+	client *Client
+	next   string
+	page   []T
+	index  int
+	cur    T
+	err    error
+	skip   func(T) bool
+	// key names the field holding the items for endpoints that wrap each
+	// page in an object, like {"repositories": [...]}.
+	key string
+}
+
+func newPageIterator[T any](c *Client, path string, query url.Values) *PageIterator[T] {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return &PageIterator[T]{client: c, next: path}
+}
+
+func (it *PageIterator[T]) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.index < len(it.page) {
+			it.cur = it.page[it.index]
+			it.index++
+			if it.skip != nil && it.skip(it.cur) {
+				continue
+			}
+			return true
+		}
+		if it.next == "" {
+			return false
+		}
+		it.fetch()
+	}
+}
+
+func (it *PageIterator[T]) Value() T {
+	return it.cur
+}
+
+func (it *PageIterator[T]) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice.
+func (it *PageIterator[T]) All() ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Value())
+	}
+	return items, it.Err()
+}
+
+func (it *PageIterator[T]) fetch() {
+	req, err := it.client.newRequest("GET", it.next, nil)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	var page []T
+	var wrapped map[string]json.RawMessage
+	var target interface{} = &page
+	if it.key != "" {
+		target = &wrapped
+	}
+	resp, err := it.client.do(req, target)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if it.key != "" {
+		items, ok := wrapped[it.key]
+		if !ok {
+			it.err = fmt.Errorf("unexpected response from %s: no %q field", it.next, it.key)
+			return
+		}
+		if err := json.Unmarshal(items, &page); err != nil {
+			it.err = fmt.Errorf("failed to decode %s with %v", it.key, err)
+			return
+		}
+	}
+
+	it.page = page
+	it.index = 0
+	it.next = nextPageURL(resp)
+}
+
+func nextPageURL(resp *http.Response) string {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		target := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return target
+			}
+		}
+	}
+	return ""
+}
+
+type IssueListOptions struct {
+	// State is one of "open", "closed" or "all". Defaults to "open".
+	State     string
+	Labels    []string
+	Assignee  string
+	Milestone string
+	Sort      string
+	Direction string
+	ListOptions
+}
+
+// Issues returns an iterator over the issues of repo. Pull requests, which
+// the issues endpoint also returns, are skipped.
+func (c *Client) Issues(repo config.RepoRef, opts *IssueListOptions) *PageIterator[Issue] {
+	if opts == nil {
+		opts = &IssueListOptions{}
+	}
+
+	q := url.Values{}
+	state := opts.State
+	if state == "" {
+		state = "open"
+	}
+	q.Set("state", state)
+	if len(opts.Labels) > 0 {
+		q.Set("labels", strings.Join(opts.Labels, ","))
+	}
+	if opts.Assignee != "" {
+		q.Set("assignee", opts.Assignee)
+	}
+	if opts.Milestone != "" {
+		q.Set("milestone", opts.Milestone)
+	}
+	if opts.Sort != "" {
+		q.Set("sort", opts.Sort)
+	}
+	if opts.Direction != "" {
+		q.Set("direction", opts.Direction)
+	}
+	opts.ListOptions.encode(q)
+
+	it := newPageIterator[Issue](c, c.repoPath(repo)+"/issues", q)
+	it.skip = func(issue Issue) bool { return issue.PullRequest != nil }
+	return it
+}
+
+func (c *Client) ListIssues(repo config.RepoRef, opts *IssueListOptions) ([]Issue, error) {
+	return c.Issues(repo, opts).All()
+}
+
+func (c *Client) Labels(repo config.RepoRef) *PageIterator[Label] {
+	q := url.Values{}
+	ListOptions{}.encode(q)
+	return newPageIterator[Label](c, c.repoPath(repo)+"/labels", q)
+}
+
+func (c *Client) ListLabels(repo config.RepoRef) ([]Label, error) {
+	return c.Labels(repo).All()
+}
+
+// Repos returns an iterator over the repos the signed-in user owns or can
+// access as a collaborator or organization member. App installations, which
+// cannot list /user/repos, get the repos the installation was granted.
+func (c *Client) Repos() *PageIterator[Repo] {
+	q := url.Values{}
+	ListOptions{}.encode(q)
+	if _, ok := c.TokenSource.(*InstallationTokenSource); ok {
+		it := newPageIterator[Repo](c, "installation/repositories", q)
+		it.key = "repositories"
+		return it
+	}
+	q.Set("affiliation", "owner,collaborator,organization_member")
+	return newPageIterator[Repo](c, "user/repos", q)
+}
+
+func (c *Client) ListRepos() ([]Repo, error) {
+	return c.Repos().All()
+}
@@ -0,0 +1,135 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"teriyake/go-git-it/config"
+	"testing"
+	"time"
+)
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/user/repos?page=2>; rel="next", <https://api.github.com/user/repos?page=5>; rel="last"`, "https://api.github.com/user/repos?page=2"},
+		{`<https://api.github.com/user/repos?page=1>; rel="prev", <https://api.github.com/user/repos?page=3>; rel="next"`, "https://api.github.com/user/repos?page=3"},
+		{`<https://api.github.com/user/repos?page=1>; rel="first", <https://api.github.com/user/repos?page=4>; rel="prev"`, ""},
+		{`<https://api.github.com/user/repos?page=2>`, ""},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.link != "" {
+			resp.Header.Set("Link", tt.link)
+		}
+		if got := nextPageURL(resp); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestPageIterator(t *testing.T) {
+	requests := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("page") {
+		case "":
+			if got := r.URL.Query().Get("state"); got != "all" {
+				t.Errorf("state = %q, want all", got)
+			}
+			if got := r.URL.Query().Get("per_page"); got != "100" {
+				t.Errorf("per_page = %q, want 100", got)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/me/todo/issues?page=2>; rel="next"`, srv.URL))
+			fmt.Fprint(w, `[{"number": 1}, {"number": 2, "pull_request": {}}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/me/todo/issues?page=3>; rel="next"`, srv.URL))
+			fmt.Fprint(w, `[]`)
+		case "3":
+			fmt.Fprint(w, `[{"number": 3}]`)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("t", "me")
+	c.BaseURL = srv.URL
+	it := c.Issues(config.RepoRef{Name: "todo"}, &IssueListOptions{State: "all"})
+
+	if !it.Next() || it.Value().Number != 1 {
+		t.Fatalf("first issue = %v, want #1", it.Value().Number)
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests before the first page was used up, want 1", requests)
+	}
+	// #2 is a pull request and the second page is empty.
+	if !it.Next() || it.Value().Number != 3 {
+		t.Fatalf("second issue = %v, want #3", it.Value().Number)
+	}
+	if it.Next() {
+		t.Errorf("Next after the last page = true, want false")
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Err = %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("made %d requests, want 3", requests)
+	}
+}
+
+func TestPageIteratorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := NewClient("t", "me")
+	c.BaseURL = srv.URL
+	labels, err := c.ListLabels(config.RepoRef{Name: "todo"})
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("ListLabels error = %v, want a NotFoundError", err)
+	}
+	if len(labels) != 0 {
+		t.Errorf("ListLabels = %v, want none", labels)
+	}
+}
+
+func TestReposInstallation(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/installation/repositories" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer inst" {
+			t.Errorf("Authorization = %q, want the installation token", got)
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/installation/repositories?page=2>; rel="next"`, srv.URL))
+			fmt.Fprint(w, `{"total_count": 2, "repositories": [{"name": "todo"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"total_count": 2, "repositories": [{"name": "chores"}]}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient("", "acme")
+	c.BaseURL = srv.URL
+	c.TokenSource = &InstallationTokenSource{token: &installationToken{Token: "inst", ExpiresAt: time.Now().Add(time.Hour)}}
+
+	repos, err := c.ListRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 2 || repos[0].Name != "todo" || repos[1].Name != "chores" {
+		t.Errorf("ListRepos = %+v, want todo and chores", repos)
+	}
+}
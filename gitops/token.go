@@ -0,0 +1,115 @@
+package gitops
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"teriyake/go-git-it/config"
+	"time"
+)
+
+// refreshMargin is how long before expiry a user token is refreshed.
+const refreshMargin = time.Minute
+
+// RefreshUserToken exchanges a refresh token for a new credential. It is
+// provided by gitauth.
+var RefreshUserToken func(host, refreshToken string) (*config.Credential, error)
+
+// TokenSource supplies the token for each request, for credentials that
+// change over the lifetime of a client.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// refresher is a TokenSource that can replace a token the API rejected.
+type refresher interface {
+	Refresh(stale string) error
+}
+
+// UserTokenSource serves an expiring user token, such as a GitHub App user
+// token from the device flow, refreshing it shortly before it expires and
+// saving the new credential back to its store.
+type UserTokenSource struct {
+	Host  string
+	Store config.CredentialStore
+
+	mu   sync.Mutex
+	cred *config.Credential
+}
+
+func NewUserTokenSource(host string, cred *config.Credential, store config.CredentialStore) *UserTokenSource {
+	return &UserTokenSource{Host: host, Store: store, cred: cred}
+}
+
+func (s *UserTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cred.Expired(refreshMargin) {
+		if err := s.refresh(); err != nil {
+			return "", err
+		}
+	}
+	return s.cred.AccessToken, nil
+}
+
+// Refresh replaces the access token after the API rejected stale. Nothing is
+// done if the token was already refreshed in the meantime.
+func (s *UserTokenSource) Refresh(stale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cred.AccessToken != stale {
+		return nil
+	}
+	return s.refresh()
+}
+
+func (s *UserTokenSource) refresh() error {
+	if s.cred.RefreshToken == "" || RefreshUserToken == nil {
+		return config.ErrSessionExpired
+	}
+	if s.cred.RefreshTokenExpiresAt != nil && time.Now().After(*s.cred.RefreshTokenExpiresAt) {
+		return config.ErrSessionExpired
+	}
+
+	cred, err := RefreshUserToken(s.Host, s.cred.RefreshToken)
+	if err != nil {
+		return err
+	}
+	s.cred = cred
+	// Refresh tokens are single-use, so the new one must be kept.
+	if err := s.Store.Set(cred.String()); err != nil {
+		return fmt.Errorf("failed to save refreshed token with %w", err)
+	}
+	return nil
+}
+
+// reauthorize prepares a retry of req, which was rejected with 401, if the
+// client's token source can refresh the token. It returns nil if the request
+// cannot be retried.
+func (c *Client) reauthorize(req *http.Request) (*http.Request, error) {
+	r, ok := c.TokenSource.(refresher)
+	if !ok || (req.Body != nil && req.GetBody == nil) {
+		return nil, nil
+	}
+
+	stale := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if err := r.Refresh(stale); err != nil {
+		return nil, err
+	}
+	token, err := c.TokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return retry, nil
+}
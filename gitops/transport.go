@@ -0,0 +1,255 @@
+package gitops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMaxWait    = time.Minute
+	baseBackoff       = 500 * time.Millisecond
+	maxBackoff        = 10 * time.Second
+	secondaryWait     = time.Minute
+)
+
+// RateLimitError is returned when GitHub refuses a request because a primary
+// or secondary rate limit was hit and the wait was longer than the transport
+// is willing to sleep for.
+type RateLimitError struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Secondary bool
+	Message   string
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("GitHub API %s exceeded, please try again later", kind)
+	}
+	wait := time.Until(e.Reset).Round(time.Second)
+	if wait < 0 {
+		wait = 0
+	}
+	return fmt.Sprintf("GitHub API %s exceeded, try again at %s (in %s)", kind, e.Reset.Local().Format(time.Kitchen), wait)
+}
+
+// Transport is an http.RoundTripper that honours GitHub's rate-limit headers
+// and retries requests that failed for transient reasons. Idempotent requests
+// are retried on network errors and 5xx responses; any request rejected by a
+// rate limit is retried once the limit resets, as long as that is within
+// MaxWait.
+type Transport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	MaxWait    time.Duration
+
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.ResponseHeaderTimeout = 30 * time.Second
+		base = t
+	}
+	return &Transport{
+		Base:       base,
+		MaxRetries: defaultMaxRetries,
+		MaxWait:    defaultMaxWait,
+		remaining:  -1,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.waitForReset(req); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.Base.RoundTrip(req)
+		canRetry := attempt < t.MaxRetries && (req.Body == nil || req.GetBody != nil)
+
+		if err != nil {
+			if canRetry && isIdempotent(req.Method) && isTemporary(err) {
+				if err := sleep(req, backoff(attempt)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		t.record(resp)
+
+		if rl := rateLimitFromResponse(resp); rl != nil {
+			wait := time.Until(rl.Reset)
+			if canRetry && !rl.Reset.IsZero() && wait <= t.MaxWait {
+				drain(resp)
+				if err := sleep(req, wait); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return resp, nil
+		}
+
+		if resp.StatusCode >= 500 && canRetry && isIdempotent(req.Method) {
+			drain(resp)
+			if err := sleep(req, backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+// waitForReset delays a request when an earlier response already told us the
+// primary rate limit is used up, instead of spending a request to find out.
+func (t *Transport) waitForReset(req *http.Request) error {
+	t.mu.Lock()
+	remaining, reset := t.remaining, t.reset
+	t.mu.Unlock()
+
+	if remaining != 0 {
+		return nil
+	}
+	wait := time.Until(reset)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > t.MaxWait {
+		return &RateLimitError{Remaining: 0, Reset: reset}
+	}
+	return sleep(req, wait)
+}
+
+func (t *Transport) record(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	t.remaining = remaining
+	t.reset = parseReset(resp.Header)
+	t.mu.Unlock()
+}
+
+// rateLimitFromResponse reports whether resp was rejected by a primary or
+// secondary rate limit. The body is buffered so callers can still read it.
+func rateLimitFromResponse(resp *http.Response) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	var payload struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(data, &payload)
+
+	rl := &RateLimitError{Message: payload.Message}
+	rl.Limit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	rl.Remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		rl.Secondary = true
+		rl.Reset = time.Now().Add(time.Duration(secs) * time.Second)
+		return rl
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		rl.Reset = parseReset(resp.Header)
+		return rl
+	}
+	if strings.Contains(strings.ToLower(payload.Message), "secondary rate limit") {
+		rl.Secondary = true
+		rl.Reset = time.Now().Add(secondaryWait)
+		return rl
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		rl.Reset = time.Now().Add(secondaryWait)
+		return rl
+	}
+
+	return nil
+}
+
+func parseReset(h http.Header) time.Time {
+	secs, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func isTemporary(err error) bool {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return true
+	}
+	return err == io.ErrUnexpectedEOF || strings.Contains(err.Error(), "connection reset") || strings.Contains(err.Error(), "connection refused")
+}
+
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
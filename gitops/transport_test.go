@@ -0,0 +1,139 @@
+package gitops
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newResponse(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestRateLimitFromResponse(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		body      string
+		limited   bool
+		secondary bool
+	}{
+		{"ok", 200, nil, `{}`, false, false},
+		{"forbidden", 403, nil, `{"message": "Resource not accessible by integration"}`, false, false},
+		{"primary", 403, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(reset.Unix(), 10)}}, `{"message": "API rate limit exceeded"}`, true, false},
+		{"retry after", 403, http.Header{"Retry-After": {"30"}}, `{}`, true, true},
+		{"secondary message", 403, nil, `{"message": "You have exceeded a secondary rate limit."}`, true, true},
+		{"too many requests", 429, nil, ``, true, false},
+	}
+	for _, tt := range tests {
+		resp := newResponse(tt.status, tt.header, tt.body)
+		rl := rateLimitFromResponse(resp)
+		if (rl != nil) != tt.limited {
+			t.Errorf("%s: rateLimitFromResponse = %v, want limited %v", tt.name, rl, tt.limited)
+			continue
+		}
+		if rl != nil && rl.Secondary != tt.secondary {
+			t.Errorf("%s: Secondary = %v, want %v", tt.name, rl.Secondary, tt.secondary)
+		}
+		if data, _ := io.ReadAll(resp.Body); string(data) != tt.body {
+			t.Errorf("%s: body after the check = %q, want it intact", tt.name, data)
+		}
+	}
+
+	primary := rateLimitFromResponse(newResponse(403, tests[2].header, ""))
+	if !primary.Reset.Equal(reset) || primary.Remaining != 0 {
+		t.Errorf("primary limit = %+v, want reset at %v", primary, reset)
+	}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		first    *http.Response
+		attempts int
+		status   int
+	}{
+		{"server error", "GET", newResponse(502, nil, ""), 2, 200},
+		{"server error on POST", "POST", newResponse(502, nil, ""), 1, 502},
+		{"secondary limit", "POST", newResponse(403, http.Header{"Retry-After": {"0"}}, ""), 2, 200},
+		{"limit beyond MaxWait", "GET", newResponse(403, http.Header{"Retry-After": {"3600"}}, ""), 1, 403},
+		{"not a rate limit", "GET", newResponse(403, nil, `{"message": "Forbidden"}`), 1, 403},
+	}
+	for _, tt := range tests {
+		attempts := 0
+		transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return tt.first, nil
+			}
+			return newResponse(200, nil, `{}`), nil
+		}))
+		req, _ := http.NewRequest(tt.method, "https://api.github.com/user", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Errorf("%s: RoundTrip returned error: %v", tt.name, err)
+			continue
+		}
+		if resp.StatusCode != tt.status || attempts != tt.attempts {
+			t.Errorf("%s: got status %d after %d attempts, want %d after %d", tt.name, resp.StatusCode, attempts, tt.status, tt.attempts)
+		}
+	}
+}
+
+func TestTransportNetworkError(t *testing.T) {
+	attempts := 0
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("read tcp: connection reset by peer")
+		}
+		return newResponse(200, nil, `{}`), nil
+	}))
+	req, _ := http.NewRequest("GET", "https://api.github.com/user", nil)
+	if _, err := transport.RoundTrip(req); err != nil || attempts != 2 {
+		t.Errorf("RoundTrip = %v after %d attempts, want success after 2", err, attempts)
+	}
+}
+
+func TestTransportWaitsForReset(t *testing.T) {
+	attempts := 0
+	reset := time.Now().Add(time.Hour)
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		header := http.Header{}
+		header.Set("X-RateLimit-Remaining", "0")
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		return newResponse(200, header, `{}`), nil
+	}))
+
+	req, _ := http.NewRequest("GET", "https://api.github.com/user", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	// The limit is used up until after MaxWait, so the next request fails
+	// without being sent.
+	_, err := transport.RoundTrip(req)
+	var rl *RateLimitError
+	if !errors.As(err, &rl) {
+		t.Errorf("second RoundTrip error = %v, want a RateLimitError", err)
+	}
+	if attempts != 1 {
+		t.Errorf("sent %d requests, want 1", attempts)
+	}
+}
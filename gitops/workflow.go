@@ -0,0 +1,282 @@
+package gitops
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"teriyake/go-git-it/config"
+)
+
+// WorkflowPath is where a to-do repo keeps its workflow definition. Repos
+// without one use DefaultWorkflow.
+const WorkflowPath = ".ggi/workflow.json"
+
+var hexColor = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+// State is one status of a workflow, shown as a label on the task's issue.
+type State struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
+	// Transitions lists the states a task can move to from this one. An
+	// empty list allows every state.
+	Transitions []string `json:"transitions,omitempty"`
+	// Closes closes the issue when a task enters the state. Entering any
+	// other state reopens a closed issue.
+	Closes bool `json:"closes,omitempty"`
+}
+
+// Workflow is the ordered set of states a task moves through. The first
+// state is where tasks without a status start out.
+type Workflow struct {
+	States []State `json:"states"`
+}
+
+// DefaultWorkflow is the will-do, doing, done workflow ggi has always used.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{States: []State{
+		{Name: "will-do", Color: "7c6f64", Description: "Mark a task as not-yet-started"},
+		{Name: "doing", Color: "d79921", Description: "Mark a task as in-progress"},
+		{Name: "done", Color: "98971a", Description: "Mark a task as done", Closes: true},
+	}}
+}
+
+// ParseWorkflow reads and validates a workflow definition.
+func ParseWorkflow(data []byte) (*Workflow, error) {
+	var w Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("invalid workflow: %v", err)
+	}
+	if len(w.States) == 0 {
+		return nil, fmt.Errorf("invalid workflow: no states")
+	}
+
+	seen := map[string]bool{}
+	for i := range w.States {
+		s := &w.States[i]
+		s.Name = strings.TrimSpace(s.Name)
+		s.Color = strings.TrimPrefix(s.Color, "#")
+		if s.Name == "" {
+			return nil, fmt.Errorf("invalid workflow: state %d has no name", i+1)
+		}
+		if seen[strings.ToLower(s.Name)] {
+			return nil, fmt.Errorf("invalid workflow: state %s is defined twice", s.Name)
+		}
+		seen[strings.ToLower(s.Name)] = true
+		if !hexColor.MatchString(s.Color) {
+			return nil, fmt.Errorf("invalid workflow: state %s has color %q, expected six hex digits", s.Name, s.Color)
+		}
+	}
+	for _, s := range w.States {
+		for _, t := range s.Transitions {
+			if !seen[strings.ToLower(t)] {
+				return nil, fmt.Errorf("invalid workflow: state %s has a transition to unknown state %s", s.Name, t)
+			}
+		}
+	}
+	return &w, nil
+}
+
+// Names returns the names of the states in order.
+func (w *Workflow) Names() []string {
+	names := make([]string, len(w.States))
+	for i, s := range w.States {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// State looks up a state by name, ignoring case like GitHub labels do.
+func (w *Workflow) State(name string) *State {
+	for i := range w.States {
+		if strings.EqualFold(w.States[i].Name, name) {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// Index returns the position of the named state, or -1.
+func (w *Workflow) Index(name string) int {
+	for i, s := range w.States {
+		if strings.EqualFold(s.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ClosingState returns the first state that closes issues, or "" if the
+// workflow has none.
+func (w *Workflow) ClosingState() string {
+	for _, s := range w.States {
+		if s.Closes {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// Label returns the label representing the named state, or nil.
+func (w *Workflow) Label(name string) *Label {
+	s := w.State(name)
+	if s == nil {
+		return nil
+	}
+	return &Label{Name: s.Name, Description: s.Description, Color: s.Color}
+}
+
+// StatusOf returns the status label on issue, or "" if it has none.
+func (w *Workflow) StatusOf(issue *Issue) string {
+	for _, l := range issue.Labels {
+		if s := w.State(l.Name); s != nil {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// Transitions returns the states a task in state from can move to.
+func (w *Workflow) Transitions(from string) []string {
+	s := w.State(from)
+	if s == nil || len(s.Transitions) == 0 {
+		return w.Names()
+	}
+	return s.Transitions
+}
+
+// ReopenState returns the first state a task in the closing state from can
+// move to that does not close the issue, or "" if there is none.
+func (w *Workflow) ReopenState(from string) string {
+	for _, name := range w.Transitions(from) {
+		if s := w.State(name); s != nil && !s.Closes {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// CanMove reports an error unless a task may move from one state to another.
+// Tasks without a status may move to any state.
+func (w *Workflow) CanMove(from, to string) error {
+	if w.State(to) == nil {
+		return fmt.Errorf("invalid status %q, expected one of %s", to, strings.Join(w.Names(), ", "))
+	}
+	if from == "" || strings.EqualFold(from, to) {
+		return nil
+	}
+	for _, t := range w.Transitions(from) {
+		if strings.EqualFold(t, to) {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot move a task from %s to %s, expected one of %s", from, to, strings.Join(w.Transitions(from), ", "))
+}
+
+// GetWorkflow reads the workflow definition of repo from its default branch,
+// falling back to DefaultWorkflow if it has none.
+func (c *Client) GetWorkflow(repo config.RepoRef) (*Workflow, error) {
+	var result struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	err := c.call("GET", fmt.Sprintf("%s/contents/%s", c.repoPath(repo), WorkflowPath), nil, &result)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return DefaultWorkflow(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.Encoding != "base64" {
+		return nil, fmt.Errorf("unexpected encoding %q of %s", result.Encoding, WorkflowPath)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(result.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s with %v", WorkflowPath, err)
+	}
+	w, err := ParseWorkflow(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s of %s: %w", WorkflowPath, repo, err)
+	}
+	return w, nil
+}
+
+// WriteWorkflow writes w to the local clone at repoPath, then commits and
+// pushes it.
+func WriteWorkflow(repoPath string, w *Workflow) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(repoPath, WorkflowPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	addCmd := exec.Command("git", "-C", repoPath, "add", WorkflowPath)
+	if out, err := addCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git add %s failed with %v and output: %v", WorkflowPath, err, string(out))
+	}
+	commitCmd := exec.Command("git", "-C", repoPath, "commit", "-m", "Update ggi workflow")
+	if out, err := commitCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git commit failed with %v and output: %v", err, string(out))
+	}
+	pushCmd := exec.Command("git", "-C", repoPath, "push")
+	if err := pushCmd.Run(); err != nil {
+		return fmt.Errorf("git push failed with %v", err)
+	}
+	return nil
+}
+
+// SetStatus moves issue to status: the transition must be allowed by w, the
+// status label replaces any other status label while the issue's other labels
+// are kept, and the issue is closed or reopened as the new state requires.
+func (c *Client) SetStatus(repo config.RepoRef, w *Workflow, issue *Issue, status string) (*Issue, error) {
+	if err := w.CanMove(w.StatusOf(issue), status); err != nil {
+		return nil, err
+	}
+	state := w.State(status)
+
+	updated := *issue
+	if !issue.HasLabel(state.Name) {
+		labels, err := c.AddIssueLabels(repo, issue.Number, []string{state.Name})
+		if err != nil {
+			return nil, err
+		}
+		updated.Labels = labels
+	}
+	for _, l := range issue.Labels {
+		if strings.EqualFold(l.Name, state.Name) || w.State(l.Name) == nil {
+			continue
+		}
+		labels, err := c.RemoveIssueLabel(repo, issue.Number, l.Name)
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			// Someone else already removed it.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		updated.Labels = labels
+	}
+
+	if state.Closes && issue.State != "closed" {
+		return c.CloseIssue(repo, issue.Number)
+	}
+	if !state.Closes && issue.State == "closed" {
+		return c.ReopenIssue(repo, issue.Number)
+	}
+	return &updated, nil
+}
@@ -0,0 +1,210 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"teriyake/go-git-it/config"
+	"testing"
+)
+
+const reviewWorkflow = `{"states": [
+	{"name": "todo", "color": "#ededed", "transitions": ["doing"]},
+	{"name": "doing", "color": "d79921", "transitions": ["todo", "review"]},
+	{"name": "review", "color": "1d76db", "transitions": ["doing", "done"]},
+	{"name": "done", "color": "98971a", "closes": true, "transitions": ["review"]}
+]}`
+
+func TestParseWorkflow(t *testing.T) {
+	w, err := ParseWorkflow([]byte(reviewWorkflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(w.Names(), ","); got != "todo,doing,review,done" {
+		t.Errorf("Names = %s, want the states in order", got)
+	}
+	if w.States[0].Color != "ededed" {
+		t.Errorf("Color = %s, want the leading # trimmed", w.States[0].Color)
+	}
+	if w.ClosingState() != "done" || w.ReopenState("done") != "review" {
+		t.Errorf("ClosingState = %s, ReopenState = %s, want done and review", w.ClosingState(), w.ReopenState("done"))
+	}
+
+	invalid := map[string]string{
+		"not json":           `{`,
+		"no states":          `{"states": []}`,
+		"unnamed state":      `{"states": [{"name": " ", "color": "ededed"}]}`,
+		"duplicate state":    `{"states": [{"name": "todo", "color": "ededed"}, {"name": "TODO", "color": "ededed"}]}`,
+		"bad color":          `{"states": [{"name": "todo", "color": "grey"}]}`,
+		"unknown transition": `{"states": [{"name": "todo", "color": "ededed", "transitions": ["done"]}]}`,
+	}
+	for name, data := range invalid {
+		if _, err := ParseWorkflow([]byte(data)); err == nil {
+			t.Errorf("%s: ParseWorkflow should fail", name)
+		}
+	}
+}
+
+func TestCanMove(t *testing.T) {
+	w, err := ParseWorkflow([]byte(reviewWorkflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"", "done", true},
+		{"todo", "doing", true},
+		{"todo", "done", false},
+		{"doing", "Review", true},
+		{"review", "review", true},
+		{"done", "review", true},
+		{"done", "todo", false},
+		{"todo", "blocked", false},
+	}
+	for _, tt := range tests {
+		err := w.CanMove(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("CanMove(%q, %q) = %v, want ok %v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+
+	// States without transitions allow every move.
+	if err := DefaultWorkflow().CanMove("done", "will-do"); err != nil {
+		t.Errorf("default workflow CanMove(done, will-do) = %v", err)
+	}
+}
+
+// fakeIssue serves the label and edit endpoints of a single issue, #1 of
+// me/todo, and records the requests made to it.
+type fakeIssue struct {
+	issue    Issue
+	requests []string
+}
+
+func (f *fakeIssue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/repos/me/todo/issues/1/labels":
+		var body struct{ Labels []string }
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, name := range body.Labels {
+			if !f.issue.HasLabel(name) {
+				f.issue.Labels = append(f.issue.Labels, &Label{Name: name})
+			}
+		}
+		json.NewEncoder(w).Encode(f.issue.Labels)
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/repos/me/todo/issues/1/labels/"):
+		name := strings.TrimPrefix(r.URL.Path, "/repos/me/todo/issues/1/labels/")
+		var labels []*Label
+		for _, l := range f.issue.Labels {
+			if l.Name != name {
+				labels = append(labels, l)
+			}
+		}
+		f.issue.Labels = labels
+		json.NewEncoder(w).Encode(f.issue.Labels)
+	case r.Method == "PATCH" && r.URL.Path == "/repos/me/todo/issues/1":
+		var body struct{ State string }
+		json.NewDecoder(r.Body).Decode(&body)
+		f.issue.State = body.State
+		json.NewEncoder(w).Encode(f.issue)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		labels   []string
+		status   string
+		want     []string
+		requests []string
+		newState string
+	}{
+		{
+			name:     "swap status and keep other labels",
+			state:    "open",
+			labels:   []string{"errand", "will-do"},
+			status:   "doing",
+			want:     []string{"doing", "errand"},
+			requests: []string{"POST /repos/me/todo/issues/1/labels", "DELETE /repos/me/todo/issues/1/labels/will-do"},
+			newState: "open",
+		},
+		{
+			name:     "close when done",
+			state:    "open",
+			labels:   []string{"doing", "errand"},
+			status:   "done",
+			want:     []string{"done", "errand"},
+			requests: []string{"POST /repos/me/todo/issues/1/labels", "DELETE /repos/me/todo/issues/1/labels/doing", "PATCH /repos/me/todo/issues/1"},
+			newState: "closed",
+		},
+		{
+			name:     "reopen from done",
+			state:    "closed",
+			labels:   []string{"done"},
+			status:   "will-do",
+			want:     []string{"will-do"},
+			requests: []string{"POST /repos/me/todo/issues/1/labels", "DELETE /repos/me/todo/issues/1/labels/done", "PATCH /repos/me/todo/issues/1"},
+			newState: "open",
+		},
+		{
+			name:     "unchanged status",
+			state:    "open",
+			labels:   []string{"doing"},
+			status:   "doing",
+			want:     []string{"doing"},
+			newState: "open",
+		},
+	}
+	for _, tt := range tests {
+		fake := &fakeIssue{issue: Issue{Number: 1, State: tt.state}}
+		for _, name := range tt.labels {
+			fake.issue.Labels = append(fake.issue.Labels, &Label{Name: name})
+		}
+		srv := httptest.NewServer(fake)
+		c := NewClient("t", "me")
+		c.BaseURL = srv.URL
+
+		before := fake.issue
+		before.Labels = append([]*Label(nil), fake.issue.Labels...)
+		issue, err := c.SetStatus(config.RepoRef{Owner: "me", Name: "todo"}, DefaultWorkflow(), &before, tt.status)
+		srv.Close()
+		if err != nil {
+			t.Errorf("%s: SetStatus returned error: %v", tt.name, err)
+			continue
+		}
+
+		got := issue.LabelNames()
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: labels = %v, want %v", tt.name, got, tt.want)
+		}
+		if issue.State != tt.newState {
+			t.Errorf("%s: state = %s, want %s", tt.name, issue.State, tt.newState)
+		}
+		if fmt.Sprint(fake.requests) != fmt.Sprint(tt.requests) {
+			t.Errorf("%s: requests = %v, want %v", tt.name, fake.requests, tt.requests)
+		}
+	}
+}
+
+func TestSetStatusRejectsTransition(t *testing.T) {
+	w, err := ParseWorkflow([]byte(reviewWorkflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("t", "me")
+	c.BaseURL = "http://127.0.0.1:0"
+	issue := &Issue{Number: 1, State: "open", Labels: []*Label{{Name: "todo"}}}
+	if _, err := c.SetStatus(config.RepoRef{Owner: "me", Name: "todo"}, w, issue, "done"); err == nil {
+		t.Errorf("SetStatus(todo -> done) should fail without any request")
+	}
+}
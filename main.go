@@ -1,12 +1,12 @@
 package main
 
 import (
-	"log"
+	"os"
 	"teriyake/go-git-it/cmd"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		log.Fatalf("cmd.Execute err: %s", err)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,450 @@
+package tui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"sort"
+	"strings"
+	"teriyake/go-git-it/config"
+	"teriyake/go-git-it/gitops"
+	"time"
+)
+
+const (
+	refreshInterval = 30 * time.Second
+	recentDoneLimit = 20
+)
+
+type screen int
+
+const (
+	screenRepos screen = iota
+	screenBoard
+)
+
+type inputMode int
+
+const (
+	inputNone inputMode = iota
+	inputAddTask
+	inputDeadline
+)
+
+type (
+	issuesLoadedMsg struct {
+		repo     config.RepoRef
+		workflow *gitops.Workflow
+		issues   []gitops.Issue
+		err      error
+	}
+	issueUpdatedMsg struct {
+		message string
+		err     error
+	}
+	tickMsg time.Time
+)
+
+type model struct {
+	client  *gitops.Client
+	profile *config.UserProfile
+
+	screen     screen
+	repoCursor int
+	repo       config.RepoRef
+	workflow   *gitops.Workflow
+
+	// columns holds the issues of each state of workflow.
+	columns [][]gitops.Issue
+	col     int
+	rows    []int
+
+	mode  inputMode
+	input textinput.Model
+
+	loading bool
+	status  string
+	err     error
+
+	width  int
+	height int
+}
+
+// Run starts the full-screen UI and blocks until the user quits.
+func Run(client *gitops.Client, profile *config.UserProfile) error {
+	m := newModel(client, profile)
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func newModel(client *gitops.Client, profile *config.UserProfile) model {
+	input := textinput.New()
+	input.CharLimit = 256
+
+	workflow := gitops.DefaultWorkflow()
+	m := model{
+		client:   client,
+		profile:  profile,
+		workflow: workflow,
+		columns:  make([][]gitops.Issue, len(workflow.States)),
+		rows:     make([]int, len(workflow.States)),
+		input:    input,
+	}
+
+	for i, repo := range profile.ListRepos() {
+		if repo.Equal(profile.GetCurrentRepo()) {
+			m.repoCursor = i
+		}
+	}
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case tickMsg:
+		if m.screen == screenBoard && !m.loading && m.mode == inputNone {
+			return m, tea.Batch(m.loadIssues(), tick())
+		}
+		return m, tick()
+
+	case issuesLoadedMsg:
+		if msg.repo != m.repo {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.setWorkflow(msg.workflow)
+		m.setIssues(msg.issues)
+		return m, nil
+
+	case issueUpdatedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.status = msg.message
+		m.loading = true
+		return m, m.loadIssues()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.mode != inputNone {
+			return m.updateInput(msg)
+		}
+		if m.screen == screenRepos {
+			return m.updateRepos(msg)
+		}
+		return m.updateBoard(msg)
+	}
+
+	return m, nil
+}
+
+func (m model) updateRepos(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	repos := m.profile.ListRepos()
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		if m.repoCursor > 0 {
+			m.repoCursor--
+		}
+	case "down", "j":
+		if m.repoCursor < len(repos)-1 {
+			m.repoCursor++
+		}
+	case "enter":
+		if len(repos) == 0 {
+			return m, nil
+		}
+		m.repo = repos[m.repoCursor]
+		m.screen = screenBoard
+		m.col = 0
+		m.columns = make([][]gitops.Issue, len(m.workflow.States))
+		m.rows = make([]int, len(m.workflow.States))
+		m.loading = true
+		m.err = nil
+		m.status = ""
+
+		m.profile.SetCurrentRepo(m.repo)
+		if err := m.profile.Save(); err != nil {
+			m.err = fmt.Errorf("failed to save user profile with %v", err)
+		}
+		return m, m.loadIssues()
+	}
+	return m, nil
+}
+
+func (m model) updateBoard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "esc", "backspace":
+		m.screen = screenRepos
+		m.repo = config.RepoRef{}
+		m.loading = false
+		return m, nil
+	case "left", "h":
+		if m.col > 0 {
+			m.col--
+		}
+	case "right", "l":
+		if m.col < len(m.columns)-1 {
+			m.col++
+		}
+	case "up", "k":
+		if m.rows[m.col] > 0 {
+			m.rows[m.col]--
+		}
+	case "down", "j":
+		if m.rows[m.col] < len(m.columns[m.col])-1 {
+			m.rows[m.col]++
+		}
+	case "shift+left", "H", "<":
+		return m.moveSelected(-1)
+	case "shift+right", "L", ">":
+		return m.moveSelected(1)
+	case "x":
+		issue := m.selected()
+		if issue == nil || issue.State == "closed" {
+			return m, nil
+		}
+		closing := m.workflow.ClosingState()
+		if closing == "" {
+			m.err = fmt.Errorf("the workflow of %s has no status that closes tasks", m.repo)
+			return m, nil
+		}
+		m.status = fmt.Sprintf("Closing #%d...", issue.Number)
+		return m, m.changeStatus(*issue, closing)
+	case "a":
+		m.mode = inputAddTask
+		m.input.Placeholder = "task title"
+		m.input.SetValue("")
+		m.input.Focus()
+		return m, textinput.Blink
+	case "d":
+		if m.selected() == nil {
+			return m, nil
+		}
+		m.mode = inputDeadline
+		m.input.Placeholder = "YYYY-MM-DD, or - to clear"
+		m.input.SetValue("")
+		m.input.Focus()
+		return m, textinput.Blink
+	case "r":
+		m.loading = true
+		return m, m.loadIssues()
+	}
+	return m, nil
+}
+
+func (m model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = inputNone
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		mode := m.mode
+		m.mode = inputNone
+		m.input.Blur()
+		if value == "" {
+			return m, nil
+		}
+		if mode == inputAddTask {
+			m.status = "Adding task..."
+			return m, m.addTask(value, m.workflow.States[m.col].Name)
+		}
+		issue := m.selected()
+		if issue == nil {
+			return m, nil
+		}
+		var deadline *time.Time
+		if value != "-" {
+			parsed, err := gitops.ParseDeadline(value)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			deadline = &parsed
+		}
+		m.status = fmt.Sprintf("Setting deadline of #%d...", issue.Number)
+		return m, m.setDeadline(*issue, deadline)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) moveSelected(delta int) (tea.Model, tea.Cmd) {
+	issue := m.selected()
+	target := m.col + delta
+	if issue == nil || target < 0 || target >= len(m.workflow.States) {
+		return m, nil
+	}
+	status := m.workflow.States[target].Name
+	if err := m.workflow.CanMove(m.workflow.StatusOf(issue), status); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.status = fmt.Sprintf("Moving #%d to %s...", issue.Number, status)
+	return m, m.changeStatus(*issue, status)
+}
+
+func (m *model) selected() *gitops.Issue {
+	column := m.columns[m.col]
+	if len(column) == 0 {
+		return nil
+	}
+	return &column[m.rows[m.col]]
+}
+
+// setWorkflow switches the board to the columns of w, keeping the cursor in
+// range.
+func (m *model) setWorkflow(w *gitops.Workflow) {
+	m.workflow = w
+	if len(m.rows) != len(w.States) {
+		m.rows = make([]int, len(w.States))
+	}
+	if m.col >= len(w.States) {
+		m.col = len(w.States) - 1
+	}
+}
+
+// setIssues sorts issues into one column per state, ordered by priority and
+// deadline. Issues without a status label are shown in the first state, and
+// closed issues that are not in a closing state are shown in the workflow's
+// closing state.
+func (m *model) setIssues(issues []gitops.Issue) {
+	columns := make([][]gitops.Issue, len(m.workflow.States))
+	for _, issue := range issues {
+		index := m.workflow.Index(m.workflow.StatusOf(&issue))
+		if issue.State == "closed" && (index < 0 || !m.workflow.States[index].Closes) {
+			index = m.workflow.Index(m.workflow.ClosingState())
+		}
+		if index < 0 {
+			index = 0
+		}
+		columns[index] = append(columns[index], issue)
+	}
+	for _, column := range columns {
+		sort.SliceStable(column, func(i, j int) bool { return gitops.ByPriority(&column[i], &column[j]) })
+	}
+
+	m.columns = columns
+	for i := range m.rows {
+		if m.rows[i] >= len(columns[i]) {
+			m.rows[i] = len(columns[i]) - 1
+		}
+		if m.rows[i] < 0 {
+			m.rows[i] = 0
+		}
+	}
+}
+
+func (m model) loadIssues() tea.Cmd {
+	client, repo := m.client, m.repo
+	return func() tea.Msg {
+		workflow, err := client.GetWorkflow(repo)
+		if err != nil {
+			return issuesLoadedMsg{repo: repo, err: err}
+		}
+		issues, err := client.ListIssues(repo, &gitops.IssueListOptions{State: "open"})
+		if err != nil {
+			return issuesLoadedMsg{repo: repo, err: err}
+		}
+
+		it := client.Issues(repo, &gitops.IssueListOptions{
+			State:       "closed",
+			Sort:        "updated",
+			Direction:   "desc",
+			ListOptions: gitops.ListOptions{PerPage: recentDoneLimit},
+		})
+		for count := 0; count < recentDoneLimit && it.Next(); count++ {
+			issues = append(issues, it.Value())
+		}
+		return issuesLoadedMsg{repo: repo, workflow: workflow, issues: issues, err: it.Err()}
+	}
+}
+
+func (m model) changeStatus(issue gitops.Issue, status string) tea.Cmd {
+	client, repo, workflow := m.client, m.repo, m.workflow
+	return func() tea.Msg {
+		if _, err := client.SetStatus(repo, workflow, &issue, status); err != nil {
+			return issueUpdatedMsg{err: err}
+		}
+		kind := config.OpStatus
+		if s := workflow.State(status); s.Closes && issue.State != "closed" {
+			kind = config.OpClose
+		}
+		err := config.RecordOperation(config.Operation{
+			Kind:   kind,
+			Repo:   repo,
+			Issue:  issue.Number,
+			State:  issue.State,
+			Labels: issue.LabelNames(),
+		})
+		if err != nil {
+			err = fmt.Errorf("marked #%d as %s but failed to record it for undo with %v", issue.Number, status, err)
+		}
+		return issueUpdatedMsg{message: fmt.Sprintf("Marked #%d as %s.", issue.Number, status), err: err}
+	}
+}
+
+func (m model) addTask(title, status string) tea.Cmd {
+	client, repo, workflow := m.client, m.repo, m.workflow
+	return func() tea.Msg {
+		issue, err := client.CreateIssue(repo, title, "")
+		if err != nil {
+			return issueUpdatedMsg{err: err}
+		}
+		if err := config.RecordOperation(config.Operation{Kind: config.OpAdd, Repo: repo, Issue: issue.Number}); err != nil {
+			return issueUpdatedMsg{err: fmt.Errorf("added #%d but failed to record it for undo with %v", issue.Number, err)}
+		}
+		_, err = client.SetStatus(repo, workflow, issue, status)
+		return issueUpdatedMsg{message: fmt.Sprintf("Added #%d.", issue.Number), err: err}
+	}
+}
+
+func (m model) setDeadline(issue gitops.Issue, deadline *time.Time) tea.Cmd {
+	client, repo := m.client, m.repo
+	return func() tea.Msg {
+		var previous string
+		if due := issue.DueOn(); due != nil {
+			previous = due.Format("2006-01-02")
+		}
+		if _, err := client.SetIssueDeadline(repo, &issue, deadline); err != nil {
+			return issueUpdatedMsg{err: err}
+		}
+		message := fmt.Sprintf("Cleared deadline of #%d.", issue.Number)
+		if deadline != nil {
+			message = fmt.Sprintf("Set deadline of #%d to %s.", issue.Number, deadline.Format("2006-01-02"))
+		}
+		err := config.RecordOperation(config.Operation{Kind: config.OpDeadline, Repo: repo, Issue: issue.Number, Value: previous})
+		if err != nil {
+			err = fmt.Errorf("changed the deadline of #%d but failed to record it for undo with %v", issue.Number, err)
+		}
+		return issueUpdatedMsg{message: message, err: err}
+	}
+}
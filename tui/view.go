@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"teriyake/go-git-it/gitops"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#fabd2f"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#928374"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#fb4934"))
+	statusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#b8bb26"))
+	cursorStyle   = lipgloss.NewStyle().Reverse(true)
+	closedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#928374")).Strikethrough(true)
+	dueStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#83a598"))
+	priorityStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#fe8019"))
+	columnStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#504945")).Padding(0, 1)
+	focusedStyle  = columnStyle.Copy().BorderForeground(lipgloss.Color("#fabd2f"))
+)
+
+const (
+	repoHelp  = "↑/↓ select • enter open • q quit"
+	boardHelp = "←/→ column • ↑/↓ card • </> move • x close • a add • d deadline • r refresh • esc repos • q quit"
+	inputHelp = "enter confirm • esc cancel"
+)
+
+func (m model) View() string {
+	var b strings.Builder
+	if m.screen == screenRepos {
+		b.WriteString(m.reposView())
+	} else {
+		b.WriteString(m.boardView())
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.mode == inputAddTask:
+		b.WriteString("New task in " + m.workflow.States[m.col].Name + ": " + m.input.View())
+	case m.mode == inputDeadline:
+		b.WriteString("Deadline: " + m.input.View())
+	case m.err != nil:
+		b.WriteString(errorStyle.Render(m.err.Error()))
+	case m.loading:
+		b.WriteString(statusStyle.Render("Loading..."))
+	default:
+		b.WriteString(statusStyle.Render(m.status))
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.mode != inputNone:
+		b.WriteString(helpStyle.Render(inputHelp))
+	case m.screen == screenRepos:
+		b.WriteString(helpStyle.Render(repoHelp))
+	default:
+		b.WriteString(helpStyle.Render(boardHelp))
+	}
+	return b.String()
+}
+
+func (m model) reposView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("ggi — to-do repos"))
+	b.WriteString("\n\n")
+
+	repos := m.profile.ListRepos()
+	if len(repos) == 0 {
+		b.WriteString("No existing to-do repos found. Please use 'new-repo' command to create one.\n")
+		return b.String()
+	}
+
+	for i, repo := range repos {
+		line := "  " + repo.String()
+		if repo.Equal(m.profile.GetCurrentRepo()) {
+			line += helpStyle.Render(" (current)")
+		}
+		if i == m.repoCursor {
+			line = cursorStyle.Render("> " + repo.String())
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func (m model) boardView() string {
+	width := m.width
+	if width <= 0 {
+		width = 90
+	}
+	colWidth := width/len(m.workflow.States) - 4
+	if colWidth < 12 {
+		colWidth = 12
+	}
+
+	height := m.height - 8
+	if height < 4 {
+		height = 4
+	}
+
+	columns := make([]string, len(m.workflow.States))
+	for i, state := range m.workflow.States {
+		columns[i] = m.columnView(i, state, colWidth, height)
+	}
+
+	title := titleStyle.Render("ggi — " + m.repo.String())
+	return title + "\n" + lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+}
+
+func (m model) columnView(index int, state gitops.State, width, height int) string {
+	issues := m.columns[index]
+
+	header := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#" + state.Color)).Render(fmt.Sprintf("%s (%d)", state.Name, len(issues)))
+	lines := []string{header, ""}
+
+	// Each card takes two lines; scroll so the cursor stays visible.
+	visible := (height - 2) / 2
+	if visible < 1 {
+		visible = 1
+	}
+	offset := 0
+	if m.rows[index] >= visible {
+		offset = m.rows[index] - visible + 1
+	}
+
+	for i := offset; i < len(issues) && i < offset+visible; i++ {
+		issue := issues[i]
+		title := truncate(fmt.Sprintf("#%d %s", issue.Number, issue.Title), width-2)
+		switch {
+		case index == m.col && i == m.rows[index]:
+			title = cursorStyle.Render(title)
+		case issue.State == "closed":
+			title = closedStyle.Render(title)
+		}
+
+		var details []string
+		if priority := issue.Priority(); priority != "" {
+			details = append(details, priorityStyle.Render(priority))
+		}
+		if due := issue.DueOn(); due != nil {
+			details = append(details, dueStyle.Render("due "+due.Format("2006-01-02")))
+		}
+		detail := strings.Join(details, " ")
+		lines = append(lines, title, detail)
+	}
+
+	style := columnStyle
+	if index == m.col {
+		style = focusedStyle
+	}
+	return style.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}